@@ -0,0 +1,103 @@
+package giter
+
+import "testing"
+
+// these reimplement Slice, Map and Filter the way they were implemented before pulling, with every
+// stage handing each of its values over a channel from its own goroutine, to compare against.
+
+func chanSlice[T any](xs []T) Iterator[T] {
+	return Make(
+		func(values chan<- T, stopChan <-chan interface{}) {
+			for _, x := range xs {
+				select {
+				case values <- x:
+				case <-stopChan:
+					return
+				}
+			}
+		})
+}
+
+func chanMap[T, TP any](f func(T) TP, iter Iterator[T]) Iterator[TP] {
+	return Make(
+		func(out chan<- TP, stopChan <-chan interface{}) {
+			defer iter.Close()
+			for v := range iter.Each {
+				select {
+				case out <- f(v):
+				case <-stopChan:
+					return
+				}
+			}
+		})
+}
+
+func chanFilter[T any](pred func(T) bool, iter Iterator[T]) Iterator[T] {
+	return Make(
+		func(out chan<- T, stopChan <-chan interface{}) {
+			defer iter.Close()
+			for v := range iter.Each {
+				if pred(v) {
+					select {
+					case out <- v:
+					case <-stopChan:
+						return
+					}
+				}
+			}
+		})
+}
+
+func chanToSlice[T any](iter Iterator[T]) []T {
+	defer iter.Close()
+
+	out := []T{}
+	for x := range iter.Each {
+		out = append(out, x)
+	}
+
+	return out
+}
+
+func benchInput() []int {
+	xs := make([]int, 1000)
+	for i := range xs {
+		xs[i] = i
+	}
+
+	return xs
+}
+
+func even(x int) bool { return x%2 == 0 }
+
+func double(x int) int { return 2 * x }
+
+func BenchmarkPipelinePull(b *testing.B) {
+	xs := benchInput()
+
+	for i := 0; i < b.N; i++ {
+		_ = ToSlice(Map(double, Filter(even, Slice(xs))))
+	}
+}
+
+func BenchmarkPipelineEach(b *testing.B) {
+	xs := benchInput()
+
+	for i := 0; i < b.N; i++ {
+		_ = chanToSlice(Map(double, Filter(even, Slice(xs))))
+	}
+}
+
+func BenchmarkPipelineChannel(b *testing.B) {
+	xs := benchInput()
+
+	for i := 0; i < b.N; i++ {
+		_ = chanToSlice(chanMap(double, chanFilter(even, chanSlice(xs))))
+	}
+}
+
+func BenchmarkSumRangePull(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		_ = Sum(Range(0, 1000))
+	}
+}
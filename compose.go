@@ -1,113 +1,701 @@
 package giter
 
+import (
+	"container/heap"
+	"context"
+	"sync"
+)
+
 // Zip takes n iterators and gives n elements, one from each, until one iterator stops.
 // If the iterators give a different number of results from the given iterators, unless it is told
 // to stop prematurely.
+//
+// If the iterator that stops does so by failing, the returned Iterator fails with its error.
 func Zip[T any](iters ...Iterator[T]) Iterator[T] {
-	return Make(
-		func(out chan<- T, stopChan <-chan interface{}) {
-			for _, i := range iters {
-				defer i.Close()
-			}
+	ups := make([]puller[T], len(iters))
+	for i, iter := range iters {
+		ups[i] = pull(iter)
+	}
 
-			buf := make([]T, 0, len(iters))
+	// the iterator that stopped, if any, for its error.
+	var last *puller[T]
 
-			for {
-				for len(buf) < len(iters) {
-					select {
-					case x, ok := <-iters[len(buf)].Each:
-						if !ok {
-							return
-						}
-						buf = append(buf, x)
-					case <-stopChan:
-						return
-					}
+	buf := make([]T, 0, len(iters))
+	emitted := 0
+
+	return fromPuller(zippedSize(iters), puller[T]{
+		next: func(stop <-chan interface{}) (x T, ok bool) {
+			if emitted >= len(buf) {
+				if last != nil || len(ups) == 0 {
+					return x, false
 				}
 
-				for _, x := range buf {
-					select {
-					case out <- x:
-					case <-stopChan:
-						return
+				clear(&buf)
+				emitted = 0
+
+				for len(buf) < len(ups) {
+					x, ok := ups[len(buf)].next(stop)
+					if !ok {
+						last = &ups[len(buf)]
+						return x, false
 					}
+					buf = append(buf, x)
 				}
+			}
+
+			x = buf[emitted]
+			emitted++
 
-				buf = buf[:0]
+			return x, true
+		},
+		err: func() error {
+			if last == nil {
+				return nil
 			}
-		})
+
+			return last.Err()
+		},
+		close: func() {
+			clear(&buf)
+
+			for i := range ups {
+				ups[i].Close()
+			}
+		},
+	})
 }
 
-// Merge returns an iterator emitting all the values of the given iterators
+// Pair holds two values of possibly different types, as emitted by Zip2.
+type Pair[A, B any] struct {
+	First  A
+	Second B
+}
+
+// Indexed holds a value along with an index: its position among the values of an Iterator, as
+// emitted by Enumerate, or that of the iterator it came from, as emitted by MergeTagged.
+type Indexed[T any] struct {
+	Index int
+	Value T
+}
+
+// Triple holds three values of possibly different types, as emitted by Zip3.
+type Triple[A, B, C any] struct {
+	First  A
+	Second B
+	Third  C
+}
+
+// Zip2 returns an Iterator emitting pairs of the values of two given iterators, the first value of
+// each with the first of the other and so on, until either iterator stops.
 //
-// The output order is undefined.
-func Merge[T any](iters ...Iterator[T]) Iterator[T] {
-	return Make(
-		func(out chan<- T, stopChan <-chan interface{}) {
-			// no way to mux reading from n channels, so...
-
-			// we launch 0..len(iters) goroutine, each watches a stop channel to know
-			// when to bail out
-			stops := make([]chan interface{}, len(iters))
-
-			// after we spawn our goroutines, we wait to see len(iters) messages on done
-			done := make(chan interface{})
-
-			for i := range iters {
-				go func(iter Iterator[T], stop <-chan interface{}) {
-					defer iter.Close()
-
-					for {
-						select {
-						case x, ok := <-iter.Each:
-							if !ok {
-								done <- nil
-								return
-							}
-							out <- x
-						case <-stop:
-							return
-						}
+// If the iterator that stops does so by failing, the returned Iterator fails with its error.
+func Zip2[A, B any](a Iterator[A], b Iterator[B]) Iterator[Pair[A, B]] {
+	upA, upB := pull(a), pull(b)
+
+	// the Err of the iterator that stopped.
+	var err func() error
+
+	return fromPuller(shortestSize(a.size, b.size), puller[Pair[A, B]]{
+		next: func(stop <-chan interface{}) (p Pair[A, B], ok bool) {
+			if p.First, ok = upA.next(stop); !ok {
+				err = upA.Err
+				return p, false
+			}
+
+			if p.Second, ok = upB.next(stop); !ok {
+				err = upB.Err
+				return p, false
+			}
+
+			return p, true
+		},
+		err: func() error {
+			if err == nil {
+				return nil
+			}
+
+			return err()
+		},
+		close: func() {
+			upA.Close()
+			upB.Close()
+		},
+	})
+}
+
+// Zip3 is as Zip2, but for three iterators.
+func Zip3[A, B, C any](a Iterator[A], b Iterator[B], c Iterator[C]) Iterator[Triple[A, B, C]] {
+	return Map(
+		func(p Pair[Pair[A, B], C]) Triple[A, B, C] {
+			return Triple[A, B, C]{p.First.First, p.First.Second, p.Second}
+		},
+		Zip2(Zip2(a, b), c))
+}
+
+// ZipLongest is as Zip2, but continues until both iterators are exhausted, pairing the remaining
+// values of the longer one with zero values.
+//
+// Callers needing to tell padding apart from zero values emitted by the iterators can zip
+// iterators of pointers instead.
+//
+// If either iterator fails, the returned Iterator fails with its error.
+func ZipLongest[A, B any](a Iterator[A], b Iterator[B]) Iterator[Pair[A, B]] {
+	upA, upB := pull(a), pull(b)
+
+	doneA, doneB := false, false
+	var err error
+
+	return fromPuller(longestSize(a.size, b.size), puller[Pair[A, B]]{
+		next: func(stop <-chan interface{}) (p Pair[A, B], ok bool) {
+			var zeroA A
+			var zeroB B
+
+			if !doneA {
+				if p.First, ok = upA.next(stop); !ok {
+					if stopped(stop) {
+						return p, false
+					} else if err = upA.Err(); err != nil {
+						return p, false
+					}
+
+					doneA, p.First = true, zeroA
+					upA.Close()
+				}
+			}
+
+			if !doneB {
+				if p.Second, ok = upB.next(stop); !ok {
+					if stopped(stop) {
+						return p, false
+					} else if err = upB.Err(); err != nil {
+						return p, false
 					}
-				}(iters[i], stops[i])
+
+					doneB, p.Second = true, zeroB
+					upB.Close()
+				}
 			}
 
-			waiting := len(iters)
+			return p, !doneA || !doneB
+		},
+		err: func() error { return err },
+		close: func() {
+			upA.Close()
+			upB.Close()
+		},
+	})
+}
+
+// Unzip splits an Iterator of pairs into two iterators, one emitting the first value of each pair
+// and the other the second.
+//
+// The returned iterators may be consumed independently, even from different goroutines. Values
+// pulled from the given Iterator by one of them are buffered until the other emits them, so
+// consuming one far ahead of the other may buffer many values; values are no longer buffered for
+// an Iterator once it has been closed. The given Iterator is closed once both are.
+//
+// If the given Iterator fails, so do both returned ones.
+func Unzip[A, B any](iter Iterator[Pair[A, B]]) (Iterator[A], Iterator[B]) {
+	u := &unzipper[A, B]{
+		up:   pull(iter),
+		turn: make(chan interface{}, 1),
+	}
+
+	a := unzipped(u, &u.firsts, func(p Pair[A, B]) A { return p.First },
+		func(p Pair[A, B]) { u.seconds.push(p.Second) })
+
+	b := unzipped(u, &u.seconds, func(p Pair[A, B]) B { return p.Second },
+		func(p Pair[A, B]) { u.firsts.push(p.First) })
+
+	return fromPuller(iter.size, a), fromPuller(iter.size, b)
+}
+
+// unzipper holds the state shared between the iterators returned by Unzip.
+type unzipper[A, B any] struct {
+	// up may be pulled only by the side holding the turn, i.e. having sent to turn.
+	up        puller[Pair[A, B]]
+	turn      chan interface{}
+	exhausted bool
+
+	// mu guards the buffers, and the count of closed sides.
+	mu      sync.Mutex
+	firsts  branchBuffer[A]
+	seconds branchBuffer[B]
+	closed  int
+}
+
+// branchBuffer holds the values pulled for one of the iterators returned by Unzip or Tee that it
+// hasn't emitted yet.
+type branchBuffer[T any] struct {
+	xs     []T
+	closed bool
+}
+
+// push buffers x, unless the iterator is closed.
+func (buf *branchBuffer[T]) push(x T) {
+	if !buf.closed {
+		buf.xs = append(buf.xs, x)
+	}
+}
+
+// pop removes and returns the first buffered value, if any.
+func (buf *branchBuffer[T]) pop() (x T, ok bool) {
+	if len(buf.xs) == 0 {
+		return x, false
+	}
+
+	var zero T
+
+	x = buf.xs[0]
+	buf.xs[0] = zero
+	buf.xs = buf.xs[1:]
+
+	if len(buf.xs) == 0 {
+		// start over at the beginning of the allocation rather than growing past its end.
+		buf.xs = buf.xs[:0:0]
+	}
+
+	return x, true
+}
+
+// unzipped returns the puller of one side of an Unzip, given its buffer, how to get its value from
+// a pair, and how to buffer the other side's value from a pair.
+func unzipped[A, B, T any](
+	u *unzipper[A, B],
+	buf *branchBuffer[T],
+	get func(Pair[A, B]) T,
+	other func(Pair[A, B]),
+) puller[T] {
+	// pop pops a buffered value under the lock.
+	pop := func() (T, bool) {
+		u.mu.Lock()
+		defer u.mu.Unlock()
+
+		return buf.pop()
+	}
+
+	return puller[T]{
+		next: func(stop <-chan interface{}) (x T, ok bool) {
+			if x, ok := pop(); ok {
+				return x, true
+			}
+
+			select {
+			case u.turn <- nil:
+			case <-stop:
+				return x, false
+			}
+
+			defer func() { <-u.turn }()
+
+			// the other side may have buffered a value for us while we waited for our turn.
+			if x, ok := pop(); ok {
+				return x, true
+			} else if u.exhausted {
+				return x, false
+			}
+
+			p, ok := u.up.next(stop)
+			if !ok {
+				if !stopped(stop) {
+					u.exhausted = true
+				}
+
+				return x, false
+			}
+
+			u.mu.Lock()
+			other(p)
+			u.mu.Unlock()
+
+			return get(p), true
+		},
+		err: func() error {
+			// exhausted is set before the turn is handed back, so it's safe to read; the other
+			// side may be reading up's error too, which is fine.
+			return u.up.Err()
+		},
+		close: func() {
+			u.mu.Lock()
+			defer u.mu.Unlock()
+
+			if buf.closed {
+				return
+			}
+
+			buf.closed = true
+			buf.xs = nil
+			u.closed++
+
+			if u.closed == 2 {
+				u.up.Close()
+			}
+		},
+	}
+}
+
+// Tee returns n iterators, each of which emits all the values of the given Iterator.
+//
+// The returned iterators may be consumed independently, even from different goroutines. Values
+// pulled from the given Iterator by one of them are buffered for the others until they emit them,
+// up to the given number of values per iterator: an iterator that gets that far ahead of another
+// blocks until the other catches up, or is closed. A closed iterator is no longer buffered for,
+// so never holds up the others. A negative buffer is unbounded, and zero is taken as one.
+//
+// Bounded buffers thus require consuming the returned iterators concurrently, unless the given
+// Iterator emits no more values than fit in them. The given Iterator is closed once all returned
+// iterators are.
+//
+// If the given Iterator fails, so do all returned ones.
+func Tee[T any](n, buffer int, iter Iterator[T]) []Iterator[T] {
+	if buffer == 0 {
+		buffer = 1
+	}
+
+	t := &tee[T]{
+		up:     pull(iter),
+		turn:   make(chan interface{}, 1),
+		bufs:   make([]branchBuffer[T], n),
+		limit:  buffer,
+		open:   n,
+		shrunk: make(chan interface{}),
+	}
+
+	branches := make([]Iterator[T], n)
+	for i := range branches {
+		branches[i] = fromPuller(iter.size, t.branch(i))
+	}
+
+	if n == 0 {
+		t.up.Close()
+	}
+
+	return branches
+}
+
+// tee holds the state shared between the iterators returned by Tee.
+type tee[T any] struct {
+	// up may be pulled only by the branch holding the turn, i.e. having sent to turn.
+	up   puller[T]
+	turn chan interface{}
+
+	// mu guards everything below.
+	mu        sync.Mutex
+	bufs      []branchBuffer[T]
+	limit     int
+	open      int
+	exhausted bool
+
+	// shrunk is closed (and replaced) whenever a buffer shrinks or a branch closes, waking
+	// branches waiting for room in the buffers to pull more values.
+	shrunk chan interface{}
+}
+
+// full returns true if some open branch has buffered as many values as it may. It must be called
+// with mu held.
+func (t *tee[T]) full() bool {
+	if t.limit < 0 {
+		return false
+	}
+
+	for i := range t.bufs {
+		if !t.bufs[i].closed && len(t.bufs[i].xs) >= t.limit {
+			return true
+		}
+	}
+
+	return false
+}
+
+// pop pops a value buffered for branch i, waking any branches waiting for room if so. It must be
+// called with mu held.
+func (t *tee[T]) pop(i int) (T, bool) {
+	x, ok := t.bufs[i].pop()
+	if ok {
+		close(t.shrunk)
+		t.shrunk = make(chan interface{})
+	}
+
+	return x, ok
+}
+
+// branch returns the puller of the i-th branch of a Tee.
+func (t *tee[T]) branch(i int) puller[T] {
+	return puller[T]{
+		next: func(stop <-chan interface{}) (x T, ok bool) {
 			for {
+				t.mu.Lock()
+
+				if x, ok := t.pop(i); ok {
+					t.mu.Unlock()
+					return x, true
+				} else if t.exhausted {
+					t.mu.Unlock()
+					return x, false
+				} else if t.full() {
+					shrunk := t.shrunk
+					t.mu.Unlock()
+
+					select {
+					case <-shrunk:
+						continue
+					case <-stop:
+						return x, false
+					}
+				}
+
+				t.mu.Unlock()
+
 				select {
-				case <-done:
-					waiting--
+				case t.turn <- nil:
+				case <-stop:
+					return x, false
+				}
+
+				// the branch that had the turn before us may have buffered values for us, or
+				// filled the buffers, while we waited for it.
+				t.mu.Lock()
+				empty, full := len(t.bufs[i].xs) == 0, t.full()
+				t.mu.Unlock()
 
-					if waiting == 0 {
-						return
+				if !empty || full {
+					<-t.turn
+					continue
+				}
+
+				// exhausted can only change while holding the turn, so is safe to read here.
+				if t.exhausted {
+					<-t.turn
+					return x, false
+				}
+
+				x, ok = t.up.next(stop)
+
+				t.mu.Lock()
+
+				if !ok {
+					if !stopped(stop) {
+						t.exhausted = true
 					}
-				case <-stopChan:
-					for _, stop := range stops {
-						stop <- nil
+				} else {
+					for j := range t.bufs {
+						if j != i {
+							t.bufs[j].push(x)
+						}
 					}
 				}
+
+				t.mu.Unlock()
+				<-t.turn
+
+				return x, ok
+			}
+		},
+		err: func() error {
+			// the other branches may be reading up's error too, which is fine.
+			return t.up.Err()
+		},
+		close: func() {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+
+			if t.bufs[i].closed {
+				return
+			}
+
+			t.bufs[i].closed = true
+			t.bufs[i].xs = nil
+			t.open--
+
+			close(t.shrunk)
+			t.shrunk = make(chan interface{})
+
+			if t.open == 0 {
+				t.up.Close()
+			}
+		},
+	}
+}
+
+// Merge returns an iterator emitting all the values of the given iterators
+//
+// The output order is undefined, other than that each given iterator has at most one value waiting
+// to be emitted at a time, so that one iterator producing quickly can't starve the others.
+//
+// Each given iterator is consumed by a goroutine of its own. Closing the returned Iterator closes
+// all of the given iterators and returns once those goroutines have exited.
+//
+// If any of the given iterators fail, the returned Iterator fails with the first such error once
+// the others are exhausted.
+func Merge[T any](iters ...Iterator[T]) Iterator[T] {
+	return MergeContext(context.Background(), iters...)
+}
+
+// MergeTagged is as Merge, but emits each value along with the index of the given iterator that
+// emitted it.
+func MergeTagged[T any](iters ...Iterator[T]) Iterator[Indexed[T]] {
+	return merge(context.Background(), iters, func(i int, x T) Indexed[T] { return Indexed[T]{i, x} })
+}
+
+// MergeSorted returns an Iterator emitting all the values of the given iterators, each of which
+// must emit its values in the order given by less, in that same order.
+//
+// Values are pulled lazily, one at a time from whichever iterator emitted the last value; none
+// are buffered beyond one from each iterator. Equal values are emitted in the order of the
+// iterators that emitted them.
+//
+// If any of the given iterators fail, the returned Iterator fails with its error, as soon as it's
+// seen.
+func MergeSorted[T any](less func(a, b T) bool, iters ...Iterator[T]) Iterator[T] {
+	ups := make([]puller[T], len(iters))
+	for i, iter := range iters {
+		ups[i] = pull(iter)
+	}
+
+	h := &mergeHeap[T]{less: less, heads: make([]mergeHead[T], 0, len(ups))}
+
+	// the iterators whose next value is needed in the heap before the next value can be emitted:
+	// at first all of them, then the one that emitted the last value.
+	refill := make([]int, len(ups))
+	for i := range refill {
+		refill[i] = i
+	}
+
+	var err error
+
+	return fromPuller(sumSizes(iters), puller[T]{
+		next: func(stop <-chan interface{}) (x T, ok bool) {
+			for len(refill) > 0 {
+				i := refill[len(refill)-1]
+
+				x, ok := ups[i].next(stop)
+				if ok {
+					heap.Push(h, mergeHead[T]{x, i})
+				} else if stopped(stop) {
+					return x, false
+				} else if err = ups[i].Err(); err != nil {
+					return x, false
+				} else {
+					ups[i].Close()
+				}
+
+				refill = refill[:len(refill)-1]
 			}
-		})
+
+			if h.Len() == 0 {
+				return x, false
+			}
+
+			head := heap.Pop(h).(mergeHead[T])
+			refill = append(refill, head.i)
+
+			return head.x, true
+		},
+		err: func() error { return err },
+		close: func() {
+			clear(&h.heads)
+
+			for i := range ups {
+				ups[i].Close()
+			}
+		},
+	})
+}
+
+// MergeSortedBy is as MergeSorted, for iterators emitting their values in the order of the keys
+// given by key.
+func MergeSortedBy[T any, K Ordered](key func(T) K, iters ...Iterator[T]) Iterator[T] {
+	return MergeSorted(func(a, b T) bool { return key(a) < key(b) }, iters...)
+}
+
+// mergeHead is the next value of one of the iterators being merged by MergeSorted.
+type mergeHead[T any] struct {
+	x T
+	i int
+}
+
+// mergeHeap is a min-heap of the next values of the iterators being merged by MergeSorted,
+// implementing heap.Interface.
+type mergeHeap[T any] struct {
+	heads []mergeHead[T]
+	less  func(a, b T) bool
+}
+
+func (h *mergeHeap[T]) Len() int {
+	return len(h.heads)
+}
+
+func (h *mergeHeap[T]) Less(i, j int) bool {
+	a, b := h.heads[i], h.heads[j]
+
+	if h.less(a.x, b.x) {
+		return true
+	} else if h.less(b.x, a.x) {
+		return false
+	}
+
+	return a.i < b.i
+}
+
+func (h *mergeHeap[T]) Swap(i, j int) {
+	h.heads[i], h.heads[j] = h.heads[j], h.heads[i]
+}
+
+func (h *mergeHeap[T]) Push(x interface{}) {
+	h.heads = append(h.heads, x.(mergeHead[T]))
+}
+
+func (h *mergeHeap[T]) Pop() interface{} {
+	var zero mergeHead[T]
+
+	last := h.heads[len(h.heads)-1]
+	h.heads[len(h.heads)-1] = zero
+	h.heads = h.heads[:len(h.heads)-1]
+
+	return last
 }
 
 // Concat emits all the values of each the given iterators, one iterator after another (i.e. first
 // the elements of the first one, then the second, and so on).
+//
+// If one of the given iterators fails, the returned Iterator fails with its error without emitting
+// the values of the iterators after it.
 func Concat[T any](iters ...Iterator[T]) Iterator[T] {
-	return Make(
-		func(out chan<- T, stopChan <-chan interface{}) {
-			for _, iter := range iters {
-				iter := iter // sigh
-				defer iter.Close()
-			}
+	ups := make([]puller[T], len(iters))
+	for i, iter := range iters {
+		ups[i] = pull(iter)
+	}
 
-			for _, iter := range iters {
-				for x := range iter.Each {
-					select {
-					case out <- x:
-					case <-stopChan:
-						return
-					}
+	// the iterator currently being emitted, which is also the one that failed, if any.
+	current := 0
+
+	return fromPuller(sumSizes(iters), puller[T]{
+		next: func(stop <-chan interface{}) (x T, ok bool) {
+			for current < len(ups) {
+				x, ok := ups[current].next(stop)
+				if ok {
+					return x, true
+				} else if stopped(stop) || ups[current].Err() != nil {
+					return x, false
 				}
+
+				ups[current].Close()
+				current++
+			}
+
+			return x, false
+		},
+		err: func() error {
+			if current < len(ups) {
+				return ups[current].Err()
+			}
+
+			return nil
+		},
+		close: func() {
+			for i := range ups {
+				ups[i].Close()
 			}
-		})
+		},
+	})
 }
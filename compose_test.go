@@ -1,9 +1,14 @@
 package giter
 
 import (
+	"context"
+	"errors"
 	"reflect"
+	"runtime"
 	"sort"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 func TestChained(t *testing.T) {
@@ -89,9 +94,9 @@ func TestMerge(t *testing.T) {
 		t.Errorf("TestMerge: Merge({1,2}, {3,4,5}) (unordered) = %v, want = %v", out, want)
 	}
 
-	// vaguely verify that closing doesn't bug out.
-	// XXX maybe there is some decent way to make sure no goroutines that we spawned linger
-	// after the Close()? that doesn't involve getting super invasive.
+	// closing midway must leave none of the goroutines we spawned behind.
+	before := runtime.NumGoroutine()
+
 	again := Merge(slices(in...)...)
 
 	oneOut := <-again.Each
@@ -102,6 +107,96 @@ func TestMerge(t *testing.T) {
 			"TestMerge: Merge({1,2}, {3,4,5}) one entry = %v, want one of = %v",
 			oneOut, want)
 	}
+
+	checkGoroutines(t, "TestMerge", before)
+}
+
+func TestMergeCloseMidway(t *testing.T) {
+	before := runtime.NumGoroutine()
+
+	a, exitedA := naturals(context.Background())
+	b, exitedB := naturals(context.Background())
+
+	// taken receives each value of the first two inputs as it's taken by the Merge, which emits
+	// the even ones from the first and the odd ones from the second.
+	taken := []chan int{make(chan int, 16), make(chan int, 16)}
+	tap := func(i int) func(int) int {
+		return func(x int) int {
+			taken[i] <- x
+			return 2*x + i
+		}
+	}
+
+	iter := Merge(Map(tap(0), a), Map(tap(1), b), Map(func(x int) int { return -1 - x },
+		Range(0, 1000)))
+
+	received := []int{0, 0}
+
+	for i := 0; i < 10; i++ {
+		if x := <-iter.Each; x >= 0 {
+			received[x%2]++
+		}
+	}
+
+	// neither input ever finishes, and the consumer stops receiving once both have gone on to
+	// the values after those it received, which they're left unable to send.
+	for i, ch := range taken {
+		for x := range ch {
+			if x == received[i] {
+				break
+			}
+		}
+	}
+
+	iter.Close()
+
+	for _, exited := range []<-chan interface{}{exitedA, exitedB} {
+		select {
+		case <-exited:
+		default:
+			t.Errorf("TestMergeCloseMidway: input still producing after Close returned")
+		}
+	}
+
+	checkGoroutines(t, "TestMergeCloseMidway", before)
+}
+
+func TestMergeTagged(t *testing.T) {
+	in := [][]string{
+		[]string{"a", "b"},
+		[]string{"c"},
+		[]string{"d", "e", "f"},
+	}
+
+	out := ToSlice(MergeTagged(slices(in...)...))
+
+	got := make([][]string, len(in))
+	for _, x := range out {
+		got[x.Index] = append(got[x.Index], x.Value)
+	}
+
+	if !reflect.DeepEqual(got, in) {
+		t.Errorf("TestMergeTagged: values by index = %v, want %v", got, in)
+	}
+}
+
+func TestMergeFairness(t *testing.T) {
+	a, _ := naturals(context.Background())
+	b, _ := naturals(context.Background())
+
+	// a finite input, pulled in its goroutine rather than produced from one of its own, mustn't
+	// lose out to the others either.
+	c := Map(func(x int) int { return x }, Range(0, 1000000))
+
+	counts := CountBy(func(x Indexed[int]) int { return x.Index },
+		Drop(1000, Take(4000, MergeTagged(a, b, c))))
+
+	// how evenly the inputs are served is up to the runtime, but none may be starved.
+	for i := 0; i < 3; i++ {
+		if counts[i] == 0 {
+			t.Errorf("TestMergeFairness: none of the last 3000 values came from input %v", i)
+		}
+	}
 }
 
 func TestConcat(t *testing.T) {
@@ -136,3 +231,349 @@ func TestConcat(t *testing.T) {
 		t.Errorf("TestConcat: Concat(odds, evens) = %v, want = %v", out, want)
 	}
 }
+
+func TestConcatErr(t *testing.T) {
+	wantErr := errors.New("boom")
+	want := []int{1, 2}
+
+	out, err := ToSliceErr(Concat(Slice([]int{1, 2}), Fail[int](wantErr), Slice([]int{3})))
+
+	if !reflect.DeepEqual(out, want) {
+		t.Errorf("TestConcatErr: out = %v, want = %v", out, want)
+	}
+
+	if err != wantErr {
+		t.Errorf("TestConcatErr: err = %v, want = %v", err, wantErr)
+	}
+}
+
+func TestMergeErr(t *testing.T) {
+	wantErr := errors.New("boom")
+
+	_, err := ToSliceErr(Merge(Slice([]int{1, 2}), Fail[int](wantErr)))
+
+	if err != wantErr {
+		t.Errorf("TestMergeErr: err = %v, want = %v", err, wantErr)
+	}
+}
+
+func TestZip2(t *testing.T) {
+	ids := []int{1, 2, 3}
+	names := []string{"a", "b", "c", "d"}
+
+	want := []Pair[int, string]{{1, "a"}, {2, "b"}, {3, "c"}}
+
+	out := ToSlice(Zip2(Slice(ids), Slice(names)))
+
+	if !reflect.DeepEqual(out, want) {
+		t.Errorf("TestZip2: Zip2(ids, names) = %v, want = %v", out, want)
+	}
+}
+
+func TestZip3(t *testing.T) {
+	want := []Triple[int, string, bool]{{1, "a", true}, {2, "b", false}}
+
+	out := ToSlice(Zip3(Range(1, 10), Slice([]string{"a", "b"}), Slice([]bool{true, false})))
+
+	if !reflect.DeepEqual(out, want) {
+		t.Errorf("TestZip3: Zip3(...) = %v, want = %v", out, want)
+	}
+}
+
+func TestZipLongest(t *testing.T) {
+	want := []Pair[int, string]{{1, "a"}, {2, "b"}, {3, ""}}
+
+	out := ToSlice(ZipLongest(Slice([]int{1, 2, 3}), Slice([]string{"a", "b"})))
+
+	if !reflect.DeepEqual(out, want) {
+		t.Errorf("TestZipLongest: ZipLongest(...) = %v, want = %v", out, want)
+	}
+
+	wantSwapped := []Pair[string, int]{{"a", 1}, {"b", 2}, {"", 3}}
+
+	outSwapped := ToSlice(ZipLongest(Slice([]string{"a", "b"}), Slice([]int{1, 2, 3})))
+
+	if !reflect.DeepEqual(outSwapped, wantSwapped) {
+		t.Errorf("TestZipLongest: ZipLongest(...) = %v, want = %v", outSwapped, wantSwapped)
+	}
+}
+
+func TestZipErr(t *testing.T) {
+	boom := errors.New("boom")
+	failing := func() Iterator[int] { return Concat(Slice([]int{1}), Fail[int](boom)) }
+
+	if _, err := ToSliceErr(Zip2(Range(0, 10), failing())); err != boom {
+		t.Errorf("TestZipErr: Zip2 err = %v, want %v", err, boom)
+	}
+
+	if _, err := ToSliceErr(Zip2(Range(0, 1), failing())); err != nil {
+		t.Errorf("TestZipErr: Zip2 stopping before failure err = %v, want nil", err)
+	}
+
+	if _, err := ToSliceErr(ZipLongest(failing(), Range(0, 10))); err != boom {
+		t.Errorf("TestZipErr: ZipLongest err = %v, want %v", err, boom)
+	}
+}
+
+func TestUnzip(t *testing.T) {
+	pairs := []Pair[int, string]{{1, "a"}, {2, "b"}, {3, "c"}}
+
+	ids, names := Unzip(Slice(pairs))
+
+	// consume one side entirely before the other, so that the other is buffered.
+	outIDs := ToSlice(ids)
+	outNames := ToSlice(names)
+
+	if want := []int{1, 2, 3}; !reflect.DeepEqual(outIDs, want) {
+		t.Errorf("TestUnzip: ids = %v, want = %v", outIDs, want)
+	}
+
+	if want := []string{"a", "b", "c"}; !reflect.DeepEqual(outNames, want) {
+		t.Errorf("TestUnzip: names = %v, want = %v", outNames, want)
+	}
+}
+
+func TestUnzipConcurrent(t *testing.T) {
+	before := runtime.NumGoroutine()
+
+	const n = 1000
+
+	ids, doubles := Unzip(Map(func(x int) Pair[int, int] { return Pair[int, int]{x, 2 * x} },
+		Range(0, n)))
+
+	sums := make(chan int)
+
+	go func() { sums <- Sum(ids) }()
+	go func() { sums <- Sum(doubles) }()
+
+	a, b := <-sums, <-sums
+	if a+b != 3*(n*(n-1)/2) {
+		t.Errorf("TestUnzipConcurrent: sums = %v, %v, want %v in total", a, b, 3*(n*(n-1)/2))
+	}
+
+	checkGoroutines(t, "TestUnzipConcurrent", before)
+}
+
+func TestUnzipClose(t *testing.T) {
+	src, exited := naturals(context.Background())
+
+	ids, names := Unzip(Map(func(x int) Pair[int, string] { return Pair[int, string]{x, "x"} },
+		src))
+
+	if out := ToSlice(Take(3, ids)); !reflect.DeepEqual(out, []int{0, 1, 2}) {
+		t.Errorf("TestUnzipClose: ids = %v, want [0 1 2]", out)
+	}
+
+	select {
+	case <-exited:
+		t.Errorf("TestUnzipClose: upstream closed while one side remains open")
+	default:
+	}
+
+	names.Close()
+
+	select {
+	case <-exited:
+	default:
+		t.Errorf("TestUnzipClose: upstream still producing after both sides were closed")
+	}
+}
+
+func TestUnzipErr(t *testing.T) {
+	boom := errors.New("boom")
+
+	ids, names := Unzip(Concat(Slice([]Pair[int, string]{{1, "a"}}),
+		Fail[Pair[int, string]](boom)))
+
+	if _, err := ToSliceErr(ids); err != boom {
+		t.Errorf("TestUnzipErr: ids err = %v, want %v", err, boom)
+	}
+
+	if _, err := ToSliceErr(names); err != boom {
+		t.Errorf("TestUnzipErr: names err = %v, want %v", err, boom)
+	}
+}
+
+func TestMergeSorted(t *testing.T) {
+	in := [][]int{
+		[]int{1, 4, 7, 10},
+		[]int{},
+		[]int{2, 2, 8},
+		[]int{3, 5, 6, 9, 11},
+	}
+
+	want := []int{1, 2, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11}
+
+	out := ToSlice(MergeSorted(func(a, b int) bool { return a < b }, slices(in...)...))
+
+	if !reflect.DeepEqual(out, want) {
+		t.Errorf("TestMergeSorted: out = %v, want %v", out, want)
+	}
+
+	if out := ToSlice(MergeSorted(func(a, b int) bool { return a < b })); len(out) != 0 {
+		t.Errorf("TestMergeSorted: merging nothing = %v, want []", out)
+	}
+}
+
+func TestMergeSortedBy(t *testing.T) {
+	type entry struct {
+		at    string
+		shard int
+	}
+
+	shards := [][]entry{
+		[]entry{{"09:00", 0}, {"09:02", 0}, {"09:05", 0}},
+		[]entry{{"09:01", 1}, {"09:02", 1}, {"09:03", 1}},
+	}
+
+	// equal keys come out in the order of the iterators.
+	want := []entry{
+		{"09:00", 0}, {"09:01", 1}, {"09:02", 0}, {"09:02", 1}, {"09:03", 1}, {"09:05", 0},
+	}
+
+	out := ToSlice(MergeSortedBy(func(e entry) string { return e.at }, slices(shards...)...))
+
+	if !reflect.DeepEqual(out, want) {
+		t.Errorf("TestMergeSortedBy: out = %v, want %v", out, want)
+	}
+}
+
+func TestMergeSortedLazy(t *testing.T) {
+	a, exitedA := naturals(context.Background())
+	b, exitedB := naturals(context.Background())
+
+	less := func(x, y int) bool { return x < y }
+
+	want := []int{0, 0, 1, 1, 2}
+
+	if out := ToSlice(Take(5, MergeSorted(less, a, b))); !reflect.DeepEqual(out, want) {
+		t.Errorf("TestMergeSortedLazy: out = %v, want %v", out, want)
+	}
+
+	for _, exited := range []<-chan interface{}{exitedA, exitedB} {
+		select {
+		case <-exited:
+		default:
+			t.Errorf("TestMergeSortedLazy: input still producing after Close")
+		}
+	}
+}
+
+func TestMergeSortedErr(t *testing.T) {
+	boom := errors.New("boom")
+
+	out, err := ToSliceErr(MergeSorted(func(a, b int) bool { return a < b },
+		Slice([]int{1, 2, 3}), Concat(One(2), Fail[int](boom))))
+
+	if err != boom || !reflect.DeepEqual(out, []int{1, 2, 2}) {
+		t.Errorf("TestMergeSortedErr: out = %v, err = %v, want [1 2 2], %v", out, err, boom)
+	}
+}
+
+func TestTee(t *testing.T) {
+	xs := []int{1, 2, 3, 4, 5}
+
+	// a buffer as large as the iterator allows consuming the branches one after another.
+	branches := Tee(3, len(xs), Slice(xs))
+
+	for i, branch := range branches {
+		if out := ToSlice(branch); !reflect.DeepEqual(out, xs) {
+			t.Errorf("TestTee: branch %v = %v, want %v", i, out, xs)
+		}
+	}
+
+	branches = Tee(2, -1, Range(0, 1000))
+
+	if out := Sum(branches[0]); out != 999*1000/2 {
+		t.Errorf("TestTee: unbounded Sum = %v, want %v", out, 999*1000/2)
+	}
+
+	if out := Count(branches[1]); out != 1000 {
+		t.Errorf("TestTee: unbounded Count = %v, want 1000", out)
+	}
+}
+
+func TestTeeConcurrent(t *testing.T) {
+	before := runtime.NumGoroutine()
+
+	branches := Tee(2, 1, Range(0, 1000))
+
+	sum := make(chan int)
+	go func() { sum <- Sum(branches[0]) }()
+
+	histogram := CountBy(func(x int) int { return x % 10 }, branches[1])
+
+	if out := <-sum; out != 999*1000/2 {
+		t.Errorf("TestTeeConcurrent: Sum = %v, want %v", out, 999*1000/2)
+	}
+
+	for k, n := range histogram {
+		if n != 100 {
+			t.Errorf("TestTeeConcurrent: histogram[%v] = %v, want 100", k, n)
+		}
+	}
+
+	checkGoroutines(t, "TestTeeConcurrent", before)
+}
+
+func TestTeeBounded(t *testing.T) {
+	var pulled int32
+
+	src := Map(func(x int) int {
+		atomic.AddInt32(&pulled, 1)
+		return x
+	}, Range(0, 100))
+
+	branches := Tee(2, 3, src)
+
+	// the first branch can't get more than 3 values ahead of the second until the second closes.
+	done := make(chan []int)
+	go func() { done <- ToSlice(branches[0]) }()
+
+	time.Sleep(10 * time.Millisecond)
+
+	if n := atomic.LoadInt32(&pulled); n > 5 {
+		t.Errorf("TestTeeBounded: %v values pulled with none consumed by the other branch", n)
+	}
+
+	branches[1].Close()
+
+	if out := <-done; len(out) != 100 {
+		t.Errorf("TestTeeBounded: %v values emitted after the other branch closed, want 100",
+			len(out))
+	}
+}
+
+func TestTeeClose(t *testing.T) {
+	src, exited := naturals(context.Background())
+
+	branches := Tee(2, 10, src)
+
+	if out := ToSlice(Take(3, branches[0])); !reflect.DeepEqual(out, []int{0, 1, 2}) {
+		t.Errorf("TestTeeClose: Take(3, branch) = %v, want [0 1 2]", out)
+	}
+
+	select {
+	case <-exited:
+		t.Errorf("TestTeeClose: source closed while a branch remains open")
+	default:
+	}
+
+	branches[1].Close()
+
+	select {
+	case <-exited:
+	default:
+		t.Errorf("TestTeeClose: source still producing after all branches were closed")
+	}
+}
+
+func TestTeeErr(t *testing.T) {
+	boom := errors.New("boom")
+
+	for i, branch := range Tee(2, 10, Concat(Range(0, 3), Fail[int](boom))) {
+		if out, err := ToSliceErr(branch); err != boom || len(out) != 3 {
+			t.Errorf("TestTeeErr: branch %v = %v, %v, want 3 values, %v", i, out, err, boom)
+		}
+	}
+}
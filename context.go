@@ -0,0 +1,288 @@
+package giter
+
+import "context"
+
+// MakeContext creates an Iterator via a given function that produces values, tied to the lifetime
+// of a given context.
+//
+// The function receives a context derived from the given one, which is cancelled either when the
+// given context is done or when the Iterator is closed, and a channel to which it must produce the
+// values of the iterator. It should stop producing and return as soon as its context is done, which
+// a producer typically notices by selecting on ctx.Done() next to each send.
+//
+// If the given context is done before the function finishes producing, the Iterator fails with
+// ctx.Err(). Otherwise the Iterator fails with the error returned by the function, if any.
+func MakeContext[T any](
+	ctx context.Context,
+	impl func(ctx context.Context, values chan<- T) error,
+) (i Iterator[T]) {
+	inner, cancel := context.WithCancel(ctx)
+
+	// Close cancels inner as well as signaling the stop channel, so impl need only watch inner.
+	return makeErr(
+		func(values chan<- T, _ <-chan interface{}) error {
+			defer cancel()
+
+			err := impl(inner, values)
+
+			if err == nil || err == inner.Err() {
+				// either impl finished, or it stopped because its context was cancelled;
+				// only the given context being done (rather than Close) is a failure.
+				return ctx.Err()
+			}
+
+			return err
+		},
+		cancel)
+}
+
+// WithContext returns an Iterator emitting the values of the given Iterator until the given context
+// is done, at which point the returned Iterator fails with ctx.Err() and the given Iterator is
+// closed.
+//
+// If the given Iterator fails, so does the returned one.
+func WithContext[T any](ctx context.Context, iter Iterator[T]) Iterator[T] {
+	return MakeContext(ctx,
+		func(ctx context.Context, out chan<- T) error {
+			defer iter.Close()
+
+			for {
+				x, ok, err := recvContext(ctx, iter)
+				if !ok {
+					return err
+				}
+
+				select {
+				case out <- x:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+		}).sized(iter.size)
+}
+
+// recvContext receives the next value of an Iterator, giving up if the given context is done
+// first.
+//
+// ok is false if no value was received, in which case err is either ctx.Err() or the error of the
+// exhausted Iterator.
+func recvContext[T any](ctx context.Context, iter Iterator[T]) (x T, ok bool, err error) {
+	x, ok, stopped := recv(ctx.Done(), iter)
+
+	if stopped {
+		return x, false, ctx.Err()
+	} else if !ok {
+		return x, false, iter.Err()
+	}
+
+	return x, true, nil
+}
+
+// SliceContext is as Slice, but stops emitting values and fails with ctx.Err() once the given
+// context is done.
+func SliceContext[T any](ctx context.Context, xs []T) Iterator[T] {
+	return MakeContext(ctx,
+		func(ctx context.Context, values chan<- T) error {
+			for _, x := range xs {
+				select {
+				case values <- x:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+
+			return nil
+		}).sized(ExactSize(len(xs)))
+}
+
+// MapContext is as Map, but stops emitting values, closes the given Iterator and fails with
+// ctx.Err() once the given context is done.
+func MapContext[T, TP any](ctx context.Context, f func(T) TP, iter Iterator[T]) Iterator[TP] {
+	return MakeContext(ctx,
+		func(ctx context.Context, out chan<- TP) error {
+			defer iter.Close()
+
+			for {
+				v, ok, err := recvContext(ctx, iter)
+				if !ok {
+					return err
+				}
+
+				select {
+				case out <- f(v):
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+		}).sized(iter.size)
+}
+
+// FilterContext is as Filter, but stops emitting values, closes the given Iterator and fails with
+// ctx.Err() once the given context is done.
+func FilterContext[T any](ctx context.Context, pred func(T) bool, iter Iterator[T]) Iterator[T] {
+	return MakeContext(ctx,
+		func(ctx context.Context, out chan<- T) error {
+			defer iter.Close()
+
+			for {
+				v, ok, err := recvContext(ctx, iter)
+				if !ok {
+					return err
+				}
+
+				if !pred(v) {
+					continue
+				}
+
+				select {
+				case out <- v:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+		}).sized(iter.size.AtMost())
+}
+
+// ConcatContext is as Concat, but stops emitting values, closes the given iterators and fails with
+// ctx.Err() once the given context is done.
+func ConcatContext[T any](ctx context.Context, iters ...Iterator[T]) Iterator[T] {
+	return MakeContext(ctx,
+		func(ctx context.Context, out chan<- T) error {
+			for _, iter := range iters {
+				iter := iter // sigh
+				defer iter.Close()
+			}
+
+			for _, iter := range iters {
+				for {
+					x, ok, err := recvContext(ctx, iter)
+					if err != nil {
+						return err
+					} else if !ok {
+						break
+					}
+
+					select {
+					case out <- x:
+					case <-ctx.Done():
+						return ctx.Err()
+					}
+				}
+			}
+
+			return nil
+		}).sized(sumSizes(iters))
+}
+
+// MergeContext is as Merge, but stops emitting values, closes the given iterators and fails with
+// ctx.Err() once the given context is done.
+func MergeContext[T any](ctx context.Context, iters ...Iterator[T]) Iterator[T] {
+	return merge(ctx, iters, func(_ int, x T) T { return x })
+}
+
+// merge implements MergeContext and MergeTagged, emitting each value as given by a function of it
+// and the index of the iterator that emitted it.
+//
+// Each iterator gets a goroutine that sends its values to out, and only receives its iterator's
+// next value once it's sent the last one, so no iterator can get ahead of the others by more than
+// a value while they wait. Which of the goroutines blocked sending is served first is up to the
+// runtime.
+func merge[T, R any](ctx context.Context, iters []Iterator[T], tag func(int, T) R) Iterator[R] {
+	return MakeContext(ctx,
+		func(ctx context.Context, out chan<- R) error {
+			// no way to mux reading from n channels, so we launch a goroutine per
+			// iterator, each of which bails out once ctx is done.
+			ctx, cancel := context.WithCancel(ctx)
+			defer cancel()
+
+			// after we spawn our goroutines, we wait to see len(iters) messages on done,
+			// each carrying the error (if any) that stopped it.
+			done := make(chan error, len(iters))
+
+			for i := range iters {
+				go func(i int, iter Iterator[T]) {
+					var err error
+
+					// we're not a producer goroutine, so have to pass on any panic to
+					// one ourselves.
+					defer func() {
+						if v := recover(); v != nil {
+							err = newPanicError(v)
+						}
+
+						done <- err
+					}()
+
+					defer iter.Close()
+
+					for {
+						x, ok, recvErr := recvContext(ctx, iter)
+						if !ok {
+							err = recvErr
+							return
+						}
+
+						select {
+						case out <- tag(i, x):
+						case <-ctx.Done():
+							err = ctx.Err()
+							return
+						}
+					}
+				}(i, iters[i])
+			}
+
+			var firstErr error
+			var panicked *PanicError
+
+			for range iters {
+				err := <-done
+
+				if pe, ok := err.(*PanicError); ok {
+					if panicked == nil {
+						// stop everything else, then raise it once they're done.
+						panicked = pe
+						cancel()
+					}
+				} else if firstErr == nil {
+					firstErr = err
+				}
+			}
+
+			if panicked != nil {
+				panic(panicked)
+			}
+
+			return firstErr
+		}).sized(sumSizes(iters))
+}
+
+// CollectContext is as CollectErr, but stops consuming the Iterator, closes it and fails with
+// ctx.Err() once the given context is done.
+func CollectContext[T, R any](
+	ctx context.Context,
+	collector Collector[T, R],
+	iter Iterator[T],
+) (R, error) {
+	return CollectErr(collector, WithContext(ctx, iter))
+}
+
+// ToSliceContext is as ToSliceErr, but stops consuming the Iterator, closes it and fails with
+// ctx.Err() once the given context is done.
+func ToSliceContext[T any](ctx context.Context, iter Iterator[T]) ([]T, error) {
+	return CollectContext(ctx, SliceCollector[T](), iter)
+}
+
+// FoldContext is as Fold, but stops consuming the Iterator, closes it and fails with ctx.Err()
+// once the given context is done.
+func FoldContext[T, R any](
+	ctx context.Context,
+	initial R,
+	f func(next T, current R) R,
+	iter Iterator[T],
+) (R, error) {
+	return FoldErr(
+		initial,
+		func(next T, current R) (R, error) { return f(next, current), nil },
+		WithContext(ctx, iter))
+}
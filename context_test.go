@@ -0,0 +1,182 @@
+package giter
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+// naturals returns an infinite Iterator of 0, 1, 2, ..., along with a channel that is closed once
+// its producer has returned.
+func naturals(ctx context.Context) (Iterator[int], <-chan interface{}) {
+	exited := make(chan interface{})
+
+	return MakeContext(ctx,
+		func(ctx context.Context, values chan<- int) error {
+			defer close(exited)
+
+			for i := 0; ; i++ {
+				select {
+				case values <- i:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+		}), exited
+}
+
+func TestMakeContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	iter, exited := naturals(ctx)
+	defer iter.Close()
+
+	want := []int{0, 1, 2}
+	out := []int{}
+
+	for x := range iter.Each {
+		out = append(out, x)
+
+		if len(out) == len(want) {
+			cancel()
+		}
+	}
+
+	<-exited
+
+	// values may have been in flight when we cancelled.
+	if !reflect.DeepEqual(want, out[:len(want)]) {
+		t.Errorf("TestMakeContext: out = %v, want prefix %v", out, want)
+	}
+
+	if err := iter.Err(); err != context.Canceled {
+		t.Errorf("TestMakeContext: Err() = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestMakeContextClose(t *testing.T) {
+	iter, exited := naturals(context.Background())
+
+	<-iter.Each
+	iter.Close()
+
+	<-exited
+
+	for range iter.Each {
+	}
+
+	// closing isn't a failure.
+	if err := iter.Err(); err != nil {
+		t.Errorf("TestMakeContextClose: Err() = %v, want nil", err)
+	}
+}
+
+func TestContextPipeline(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	src, exited := naturals(context.Background())
+
+	iter := MapContext(ctx,
+		func(x int) int { return 2 * x },
+		FilterContext(ctx,
+			func(x int) bool { return x%3 == 0 },
+			WithContext(ctx, src)))
+	defer iter.Close()
+
+	if x := <-iter.Each; x != 0 {
+		t.Errorf("TestContextPipeline: first value = %v, want %v", x, 0)
+	}
+
+	cancel()
+
+	for range iter.Each {
+	}
+
+	// cancelling must close the upstream source even though it doesn't know about ctx.
+	<-exited
+
+	if err := iter.Err(); err != context.Canceled {
+		t.Errorf("TestContextPipeline: Err() = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestSliceContext(t *testing.T) {
+	xs := []int{1, 2, 3}
+
+	out, err := ToSliceContext(context.Background(), SliceContext(context.Background(), xs))
+
+	if !reflect.DeepEqual(xs, out) || err != nil {
+		t.Errorf("TestSliceContext: out, err = %v, %v, want %v, nil", out, err, xs)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cancel()
+
+	_, err = ToSliceErr(SliceContext(ctx, xs))
+
+	if err != context.Canceled {
+		t.Errorf("TestSliceContext: err = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestConcatContext(t *testing.T) {
+	want := []int{1, 2, 3, 4}
+
+	out, err := ToSliceErr(
+		ConcatContext(context.Background(), Slice([]int{1, 2}), Slice([]int{3, 4})))
+
+	if !reflect.DeepEqual(want, out) || err != nil {
+		t.Errorf("TestConcatContext: out, err = %v, %v, want %v, nil", out, err, want)
+	}
+}
+
+func TestMergeContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	a, aExited := naturals(context.Background())
+	b, bExited := naturals(context.Background())
+
+	iter := MergeContext(ctx, a, b)
+	defer iter.Close()
+
+	<-iter.Each
+	cancel()
+
+	for range iter.Each {
+	}
+
+	<-aExited
+	<-bExited
+
+	if err := iter.Err(); err != context.Canceled {
+		t.Errorf("TestMergeContext: Err() = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestFoldContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	src, exited := naturals(context.Background())
+
+	var seen int
+	_, err := FoldContext(ctx, 0,
+		func(x, cur int) int {
+			seen++
+			if seen == 3 {
+				cancel()
+			}
+			return cur + x
+		},
+		src)
+
+	<-exited
+
+	if err != context.Canceled {
+		t.Errorf("TestFoldContext: err = %v, want %v", err, context.Canceled)
+	}
+}
@@ -18,4 +18,6 @@ func TestExample(_ *testing.T) {
 			func(f foo) KVPair[int, foo] {
 				return KVPair[int, foo]{f.id, f}
 			}, Slice([]foo{foo{1, "hi"}})))
+
+	_ = GroupBy(func(f foo) int { return f.id }, Slice([]foo{foo{1, "hi"}, foo{1, "there"}}))
 }
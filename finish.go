@@ -2,21 +2,123 @@ package giter
 
 // ToSlice consumes an iterator and returns the values in a slice.
 func ToSlice[T any](iter Iterator[T]) []T {
-	return Collect(SliceCollector[T](), iter)
+	out, _ := ToSliceErr(iter)
+	return out
+}
+
+// ToSliceErr consumes an iterator and returns the values in a slice, along with the error that
+// ended the iterator, if any.
+//
+// The slice is preallocated if the iterator's size is known exactly.
+func ToSliceErr[T any](iter Iterator[T]) ([]T, error) {
+	out := make([]T, 0, iter.size.capacity())
+
+	err := each(iter, func(x T) bool {
+		out = append(out, x)
+		return true
+	})
+
+	return out, err
 }
 
 // ToMap consumes an iterator of KVPair key-value pairs and returns a map.
 func ToMap[K comparable, V any](iter Iterator[KVPair[K, V]]) map[K]V {
-	return Collect(MapCollector[K, V](), iter)
+	out, _ := ToMapErr(iter)
+	return out
+}
+
+// ToMapErr consumes an iterator of KVPair key-value pairs and returns a map, along with the error
+// that ended the iterator, if any.
+//
+// The map is preallocated if the iterator's size is known exactly.
+func ToMapErr[K comparable, V any](iter Iterator[KVPair[K, V]]) (map[K]V, error) {
+	out := make(map[K]V, iter.size.capacity())
+
+	err := each(iter, func(x KVPair[K, V]) bool {
+		out[x.Key] = x.Value
+		return true
+	})
+
+	return out, err
+}
+
+// ToMapWith consumes an iterator of KVPair key-value pairs and returns a map, resolving values
+// emitted for the same key by calling a given function with the value mapped so far and the one
+// just emitted.
+func ToMapWith[K comparable, V any](
+	merge func(current, next V) V,
+	iter Iterator[KVPair[K, V]],
+) map[K]V {
+	out, _ := ToMapWithErr(merge, iter)
+	return out
+}
+
+// ToMapWithErr is as ToMapWith, but additionally returns the error that ended the iterator, if
+// any.
+func ToMapWithErr[K comparable, V any](
+	merge func(current, next V) V,
+	iter Iterator[KVPair[K, V]],
+) (map[K]V, error) {
+	out := make(map[K]V, iter.size.capacity())
+
+	err := each(iter, func(x KVPair[K, V]) bool {
+		if current, ok := out[x.Key]; ok {
+			out[x.Key] = merge(current, x.Value)
+		} else {
+			out[x.Key] = x.Value
+		}
+
+		return true
+	})
+
+	return out, err
+}
+
+// GroupBy consumes an iterator and returns a map of the keys given by a given function to the
+// values with that key, in the order they were emitted.
+func GroupBy[T any, K comparable](key func(T) K, iter Iterator[T]) map[K][]T {
+	out := map[K][]T{}
+
+	_ = each(iter, func(x T) bool {
+		k := key(x)
+		out[k] = append(out[k], x)
+
+		return true
+	})
+
+	return out
+}
+
+// CountBy consumes an iterator and returns a map of the keys given by a given function to how many
+// values had that key.
+func CountBy[T any, K comparable](key func(T) K, iter Iterator[T]) map[K]int {
+	out := map[K]int{}
+
+	_ = each(iter, func(x T) bool {
+		out[key(x)]++
+		return true
+	})
+
+	return out
 }
 
 // A Collector consumes the values of an Iterator and returns some aggregated value.
 type Collector[T, R any] func(<-chan T) R
 
+// A SizedCollector is a Collector that is also given a hint of how many values it will consume,
+// e.g. so that it can preallocate.
+type SizedCollector[T, R any] func(size SizeHint, each <-chan T) R
+
 // MapCollector returns a Collectors that creates a map from an Iterator of KVPairs.
 func MapCollector[K comparable, V any]() Collector[KVPair[K, V], map[K]V] {
-	return func(each <-chan KVPair[K, V]) map[K]V {
-		out := map[K]V{}
+	return unsized(SizedMapCollector[K, V]())
+}
+
+// SizedMapCollector returns a SizedCollector that creates a map from an Iterator of KVPairs,
+// preallocated if the Iterator's size is known exactly.
+func SizedMapCollector[K comparable, V any]() SizedCollector[KVPair[K, V], map[K]V] {
+	return func(size SizeHint, each <-chan KVPair[K, V]) map[K]V {
+		out := make(map[K]V, size.capacity())
 
 		for x := range each {
 			out[x.Key] = x.Value
@@ -27,8 +129,14 @@ func MapCollector[K comparable, V any]() Collector[KVPair[K, V], map[K]V] {
 
 // SliceCollector returns a Collector that creates a slice from an Iterator's values.
 func SliceCollector[V any]() Collector[V, []V] {
-	return func(each <-chan V) []V {
-		out := []V{}
+	return unsized(SizedSliceCollector[V]())
+}
+
+// SizedSliceCollector returns a SizedCollector that creates a slice from an Iterator's values,
+// preallocated if the Iterator's size is known exactly.
+func SizedSliceCollector[V any]() SizedCollector[V, []V] {
+	return func(size SizeHint, each <-chan V) []V {
+		out := make([]V, 0, size.capacity())
 
 		for x := range each {
 			out = append(out, x)
@@ -37,47 +145,274 @@ func SliceCollector[V any]() Collector[V, []V] {
 	}
 }
 
+// GroupByCollector returns a Collector that creates a map of the keys given by a given function to
+// the values with that key, as GroupBy does.
+func GroupByCollector[T any, K comparable](key func(T) K) Collector[T, map[K][]T] {
+	return func(each <-chan T) map[K][]T {
+		out := map[K][]T{}
+
+		for x := range each {
+			k := key(x)
+			out[k] = append(out[k], x)
+		}
+		return out
+	}
+}
+
+// unsized returns a Collector calling a SizedCollector with an unknown size.
+func unsized[T, R any](collector SizedCollector[T, R]) Collector[T, R] {
+	return func(each <-chan T) R {
+		return collector(SizeHint{}, each)
+	}
+}
+
 // Collect creates a value resulting from consuming an Iterator's values via a Collector.
 func Collect[T, R any](collector Collector[T, R], iter Iterator[T]) R {
 	defer iter.Close()
 	return collector(iter.Each)
 }
 
+// CollectSized creates a value resulting from consuming an Iterator's values via a SizedCollector,
+// which is given the Iterator's size hint.
+func CollectSized[T, R any](collector SizedCollector[T, R], iter Iterator[T]) R {
+	defer iter.Close()
+	return collector(iter.size, iter.Each)
+}
+
+// CollectErr creates a value resulting from consuming an Iterator's values via a Collector, along
+// with the error that ended the Iterator, if any.
+//
+// When an error is returned, the value is what the Collector made of the values emitted before the
+// Iterator failed.
+func CollectErr[T, R any](collector Collector[T, R], iter Iterator[T]) (R, error) {
+	defer iter.Close()
+	out := collector(iter.Each)
+
+	// the Collector needn't have drained Each, and Err may only be called once Close has returned.
+	iter.Close()
+
+	return out, iter.Err()
+}
+
 // Fold returns the value resulting from calling a given function with an initial value and each
 // value emitted by the iterator, updating the initial value with each invocation.
 func Fold[T, R any](initial R, f func(next T, current R) R, iter Iterator[T]) R {
-	defer iter.Close()
-
-	for x := range iter.Each {
+	_ = each(iter, func(x T) bool {
 		initial = f(x, initial)
-	}
+		return true
+	})
 
 	return initial
 }
 
-// First returns the first value emitted by an Iterator, if any.
-func First[T any](iter Iterator[T]) *T {
-	defer iter.Close()
+// FoldErr is as Fold, but with a fallible function, and additionally returns the error that ended
+// the iteration, if any: either the first error returned by the function, or the error of the
+// Iterator.
+//
+// When an error is returned, the value is the result of folding the values seen before the error.
+func FoldErr[T, R any](
+	initial R,
+	f func(next T, current R) (R, error),
+	iter Iterator[T],
+) (R, error) {
+	var err error
 
-	for x := range iter.Each {
-		return &x
+	iterErr := each(iter, func(x T) bool {
+		var next R
+
+		next, err = f(x, initial)
+		if err != nil {
+			return false
+		}
+
+		initial = next
+
+		return true
+	})
+
+	if err != nil {
+		return initial, err
 	}
 
-	return nil
+	return initial, iterErr
+}
+
+// First returns a pointer to the first value emitted by an Iterator, or nil if there's none.
+func First[T any](iter Iterator[T]) *T {
+	return FirstOption(iter).Ptr()
 }
 
-// Last returns the last value emitted by an Iterator, if any.
+// FirstOption is as First, but returns the value as an Option rather than behind a pointer.
+func FirstOption[T any](iter Iterator[T]) Option[T] {
+	var out Option[T]
+
+	_ = each(iter, func(x T) bool {
+		out = OptionOf(x)
+		return false
+	})
+
+	return out
+}
+
+// Last returns a pointer to the last value emitted by an Iterator, or nil if there's none.
 func Last[T any](iter Iterator[T]) *T {
-	defer iter.Close()
+	return LastOption(iter).Ptr()
+}
 
-	var out *T
-	for x := range iter.Each {
-		out = &x
-	}
+// LastOption is as Last, but returns the value as an Option rather than behind a pointer.
+func LastOption[T any](iter Iterator[T]) Option[T] {
+	var out Option[T]
+
+	_ = each(iter, func(x T) bool {
+		out = OptionOf(x)
+		return true
+	})
 
 	return out
 }
 
+// Count consumes an Iterator and returns how many values it emitted.
+func Count[T any](iter Iterator[T]) int {
+	n := 0
+
+	_ = each(iter, func(T) bool {
+		n++
+		return true
+	})
+
+	return n
+}
+
+// Min returns the least value emitted by an Iterator (the first of them, if several are equal), if
+// any.
+func Min[T Ordered](iter Iterator[T]) Option[T] {
+	return MinFunc(func(a, b T) bool { return a < b }, iter)
+}
+
+// Max returns the greatest value emitted by an Iterator (the first of them, if several are equal),
+// if any.
+func Max[T Ordered](iter Iterator[T]) Option[T] {
+	return MaxFunc(func(a, b T) bool { return a < b }, iter)
+}
+
+// MinBy returns the value emitted by an Iterator with the least key, as given by a given function
+// (the first of them, if several are equal), if any.
+//
+// The key function is called once per value.
+func MinBy[T any, K Ordered](key func(T) K, iter Iterator[T]) Option[T] {
+	return extremeBy(key, func(a, b K) bool { return a < b }, iter)
+}
+
+// MaxBy returns the value emitted by an Iterator with the greatest key, as given by a given
+// function (the first of them, if several are equal), if any.
+//
+// The key function is called once per value.
+func MaxBy[T any, K Ordered](key func(T) K, iter Iterator[T]) Option[T] {
+	return extremeBy(key, func(a, b K) bool { return b < a }, iter)
+}
+
+// MinFunc returns the least value emitted by an Iterator according to a given less function (the
+// first of them, if several are equal), if any.
+func MinFunc[T any](less func(a, b T) bool, iter Iterator[T]) Option[T] {
+	return extremeBy(func(x T) T { return x }, less, iter)
+}
+
+// MaxFunc returns the greatest value emitted by an Iterator according to a given less function
+// (the first of them, if several are equal), if any.
+func MaxFunc[T any](less func(a, b T) bool, iter Iterator[T]) Option[T] {
+	return extremeBy(func(x T) T { return x }, func(a, b T) bool { return less(b, a) }, iter)
+}
+
+// extremeBy returns the first value emitted by an Iterator whose key no other value's key precedes
+// according to a given function, if any.
+func extremeBy[T, K any](key func(T) K, precedes func(a, b K) bool, iter Iterator[T]) Option[T] {
+	var out Option[T]
+	var best K
+
+	_ = each(iter, func(x T) bool {
+		k := key(x)
+
+		if out.ok && !precedes(k, best) {
+			return true
+		}
+
+		out, best = OptionOf(x), k
+
+		return true
+	})
+
+	return out
+}
+
+// Average returns the arithmetic mean of the numbers emitted by an Iterator, if any.
+//
+// The mean is computed in float64, so integers don't overflow or truncate; see Mean for computing
+// it in the numbers' own type.
+func Average[T Real](iter Iterator[T]) Option[float64] {
+	var sum float64
+	n := 0
+
+	_ = each(iter, func(x T) bool {
+		sum += float64(x)
+		n++
+
+		return true
+	})
+
+	if n == 0 {
+		return Option[float64]{}
+	}
+
+	return OptionOf(sum / float64(n))
+}
+
+// Mean returns the arithmetic mean of the numbers emitted by an Iterator, if any.
+//
+// Unlike Average, the mean is of the numbers' own type. Integers are summed as int64 or uint64,
+// which may still overflow, and their mean is truncated; floating point numbers are summed as
+// float64. See MeanComplex for complex numbers.
+func Mean[T Real](iter Iterator[T]) Option[T] {
+	var zero T
+
+	switch {
+	case T(1)/T(2) != 0:
+		return meanOf(iter, func(x T) float64 { return float64(x) },
+			func(sum float64, n int) T { return T(sum / float64(n)) })
+	case zero-1 < zero:
+		return meanOf(iter, func(x T) int64 { return int64(x) },
+			func(sum int64, n int) T { return T(sum / int64(n)) })
+	default:
+		return meanOf(iter, func(x T) uint64 { return uint64(x) },
+			func(sum uint64, n int) T { return T(sum / uint64(n)) })
+	}
+}
+
+// MeanComplex is as Mean, for complex numbers, which are summed as complex128.
+func MeanComplex[T Complex](iter Iterator[T]) Option[T] {
+	return meanOf(iter, func(x T) complex128 { return complex128(x) },
+		func(sum complex128, n int) T { return T(sum / complex(float64(n), 0)) })
+}
+
+// meanOf implements Mean and MeanComplex, summing the numbers emitted by an Iterator as type S,
+// wide enough for many of them, and dividing the sum by their count via a given function.
+func meanOf[T, S Number](iter Iterator[T], widen func(T) S, divide func(S, int) T) Option[T] {
+	var sum S
+	n := 0
+
+	_ = each(iter, func(x T) bool {
+		sum += widen(x)
+		n++
+
+		return true
+	})
+
+	if n == 0 {
+		return Option[T]{}
+	}
+
+	return OptionOf(divide(sum, n))
+}
+
 // i should choose one of these :/
 
 // Some returns true if some value emitted by a given Iterator matches a given predicate.
@@ -92,13 +427,73 @@ func Has[T any](pred func(T) bool, iter Iterator[T]) bool {
 
 // Any returns true if some value emitted by a given Iterator matches a given predicate.
 func Any[T any](pred func(T) bool, iter Iterator[T]) bool {
-	defer iter.Close()
+	found := false
 
-	for x := range iter.Each {
-		if pred(x) {
-			return true
+	_ = each(iter, func(x T) bool {
+		found = pred(x)
+		return !found
+	})
+
+	return found
+}
+
+// All returns true if every value emitted by a given Iterator matches a given predicate, as is
+// the case if it emits no values at all.
+func All[T any](pred func(T) bool, iter Iterator[T]) bool {
+	return !Any(func(x T) bool { return !pred(x) }, iter)
+}
+
+// None returns true if no value emitted by a given Iterator matches a given predicate.
+func None[T any](pred func(T) bool, iter Iterator[T]) bool {
+	return !Any(pred, iter)
+}
+
+// Find returns the first value emitted by a given Iterator that matches a given predicate, if any.
+func Find[T any](pred func(T) bool, iter Iterator[T]) Option[T] {
+	return FirstOption(Filter(pred, iter))
+}
+
+// Position returns the index of the first value emitted by a given Iterator that matches a given
+// predicate, or -1 if none does.
+func Position[T any](pred func(T) bool, iter Iterator[T]) int {
+	i, found := 0, false
+
+	_ = each(iter, func(x T) bool {
+		if found = pred(x); !found {
+			i++
 		}
+
+		return !found
+	})
+
+	if !found {
+		return -1
+	}
+
+	return i
+}
+
+// Nth returns the value emitted by a given Iterator at the given index, counting from zero, if it
+// emits that many values.
+func Nth[T any](n int, iter Iterator[T]) Option[T] {
+	if n < 0 {
+		iter.Close()
+		return Option[T]{}
 	}
 
-	return false
+	var out Option[T]
+	i := 0
+
+	_ = each(iter, func(x T) bool {
+		if i < n {
+			i++
+			return true
+		}
+
+		out = OptionOf(x)
+
+		return false
+	})
+
+	return out
 }
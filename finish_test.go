@@ -1,6 +1,8 @@
 package giter
 
 import (
+	"context"
+	"errors"
 	"reflect"
 	"testing"
 )
@@ -51,6 +53,34 @@ func TestCollectToSlice(t *testing.T) {
 	}
 }
 
+func TestCollectErr(t *testing.T) {
+	boom := errors.New("boom")
+	failing := func() Iterator[int] {
+		return MakeErr(func(out chan<- int, stop <-chan interface{}) error {
+			select {
+			case out <- 1:
+			case <-stop:
+				return nil
+			}
+
+			return boom
+		})
+	}
+
+	out, err := CollectErr(SliceCollector[int](), failing())
+
+	if !reflect.DeepEqual(out, []int{1}) || err != boom {
+		t.Errorf("TestCollectErr: out = %v, %v, want [1], %v", out, err, boom)
+	}
+
+	// a Collector returning before Each is drained mustn't race with the producer failing.
+	first := func(each <-chan int) int { return <-each }
+
+	if out, err := CollectErr(first, failing()); out != 1 || (err != nil && err != boom) {
+		t.Errorf("TestCollectErr: first = %v, %v, want 1", out, err)
+	}
+}
+
 func TestScalarFold(t *testing.T) {
 	xs := []int{1, 2, 3, 4, 5}
 
@@ -99,6 +129,26 @@ func TestFirst(t *testing.T) {
 	}
 }
 
+func TestFirstLastOption(t *testing.T) {
+	xs := []int{0, 1, 2}
+
+	if out := FirstOption(Slice(xs)); out != OptionOf(0) {
+		t.Errorf("TestFirstLastOption: FirstOption(%v) = %v, want Some(0)", xs, out)
+	}
+
+	if out := LastOption(Slice(xs)); out != OptionOf(2) {
+		t.Errorf("TestFirstLastOption: LastOption(%v) = %v, want Some(2)", xs, out)
+	}
+
+	if out := FirstOption(Slice([]int{})); out.IsSome() {
+		t.Errorf("TestFirstLastOption: FirstOption({}) = %v, want None", out)
+	}
+
+	if out := LastOption(Slice([]int{})); out.IsSome() {
+		t.Errorf("TestFirstLastOption: LastOption({}) = %v, want None", out)
+	}
+}
+
 func ptrTargetEquals[T comparable](x, y *T) bool {
 	if (x == nil) != (y == nil) {
 		return false
@@ -152,3 +202,312 @@ func TestAny(t *testing.T) {
 		t.Errorf("TestAny: Any(hueg, xs) is true, should be false")
 	}
 }
+
+func TestToSliceErr(t *testing.T) {
+	xs := []int{1, 2, 3, 4, 5}
+
+	want := make([]int, len(xs))
+	copy(want, xs)
+
+	out, err := ToSliceErr(Slice(xs))
+
+	if !reflect.DeepEqual(want, out) || err != nil {
+		t.Errorf("TestToSliceErr: out, err = %v, %v, want %v, nil", out, err, want)
+	}
+
+	wantErr := errors.New("boom")
+
+	out, err = ToSliceErr(Fail[int](wantErr))
+
+	if len(out) != 0 || err != wantErr {
+		t.Errorf("TestToSliceErr: out, err = %v, %v, want [], %v", out, err, wantErr)
+	}
+}
+
+func TestFoldErr(t *testing.T) {
+	xs := []int{1, 2, 3, 4, 5}
+	wantErr := errors.New("boom")
+
+	out, err := FoldErr(
+		0,
+		func(x, cur int) (int, error) {
+			if x > 3 {
+				return cur, wantErr
+			}
+
+			return cur + x, nil
+		},
+		Slice(xs))
+
+	if out != 6 || err != wantErr {
+		t.Errorf("TestFoldErr: out, err = %v, %v, want %v, %v", out, err, 6, wantErr)
+	}
+
+	out, err = FoldErr(
+		0,
+		func(x, cur int) (int, error) { return cur + x, nil },
+		Concat(Slice(xs), Fail[int](wantErr)))
+
+	if out != 15 || err != wantErr {
+		t.Errorf("TestFoldErr: out, err = %v, %v, want %v, %v", out, err, 15, wantErr)
+	}
+}
+
+func TestCount(t *testing.T) {
+	if out := Count(Filter(func(x int) bool { return x%2 == 0 }, Range(0, 10))); out != 5 {
+		t.Errorf("TestCount: Count(evens in [0, 10)) = %v, want 5", out)
+	}
+
+	if out := Count(Slice([]int{})); out != 0 {
+		t.Errorf("TestCount: Count({}) = %v, want 0", out)
+	}
+}
+
+func TestMinMax(t *testing.T) {
+	xs := []int{3, 1, 4, 1, 5, 9, 2, 6}
+
+	if out := Min(Slice(xs)); out != OptionOf(1) {
+		t.Errorf("TestMinMax: Min(%v) = %v, want 1", xs, out)
+	}
+
+	if out := Max(Slice(xs)); out != OptionOf(9) {
+		t.Errorf("TestMinMax: Max(%v) = %v, want 9", xs, out)
+	}
+
+	if out := Max(Slice([]string{"b", "c", "a"})); out != OptionOf("c") {
+		t.Errorf("TestMinMax: Max({b, c, a}) = %v, want c", out)
+	}
+
+	if out := Min(Slice([]int{})); out.IsSome() {
+		t.Errorf("TestMinMax: Min({}) = %v, want None", out)
+	}
+
+	if out := Max(Slice([]int{})); out.IsSome() {
+		t.Errorf("TestMinMax: Max({}) = %v, want None", out)
+	}
+}
+
+func TestMinMaxBy(t *testing.T) {
+	type person struct {
+		name string
+		age  int
+	}
+
+	people := []person{{"a", 30}, {"b", 20}, {"c", 40}, {"d", 20}, {"e", 40}}
+	age := func(p person) int { return p.age }
+	older := func(p, q person) bool { return p.age < q.age }
+
+	// ties go to the first value either way.
+	tests := []struct {
+		name string
+		out  Option[person]
+		want string
+	}{
+		{"MinBy", MinBy(age, Slice(people)), "b"},
+		{"MaxBy", MaxBy(age, Slice(people)), "c"},
+		{"MinFunc", MinFunc(older, Slice(people)), "b"},
+		{"MaxFunc", MaxFunc(older, Slice(people)), "c"},
+	}
+
+	for _, test := range tests {
+		if p, ok := test.out.Get(); !ok || p.name != test.want {
+			t.Errorf("TestMinMaxBy: %v = %v, want %v", test.name, test.out, test.want)
+		}
+	}
+
+	if out := MinBy(age, Slice([]person{})); out.IsSome() {
+		t.Errorf("TestMinMaxBy: MinBy({}) = %v, want None", out)
+	}
+}
+
+func TestAverage(t *testing.T) {
+	if out := Average(Slice([]int{1, 2, 3, 4})); out != OptionOf(2.5) {
+		t.Errorf("TestAverage: Average({1, 2, 3, 4}) = %v, want 2.5", out)
+	}
+
+	if out := Average(Slice([]int{})); out.IsSome() {
+		t.Errorf("TestAverage: Average({}) = %v, want None", out)
+	}
+
+	if out := Mean(Slice([]int{1, 2, 3, 4})); out != OptionOf(2) {
+		t.Errorf("TestAverage: Mean({1, 2, 3, 4}) = %v, want 2", out)
+	}
+
+	if out := MeanComplex(Slice([]complex128{1 + 1i, 3 - 3i})); out != OptionOf(2-1i) {
+		t.Errorf("TestAverage: MeanComplex({1+1i, 3-3i}) = %v, want (2-1i)", out)
+	}
+
+	if out := Mean(Slice([]float64{})); out.IsSome() {
+		t.Errorf("TestAverage: Mean({}) = %v, want None", out)
+	}
+
+	// counts and sums of more values than small types can hold mustn't overflow.
+	if out := Mean(Map(func(int) uint8 { return 200 }, Range(0, 300))); out != OptionOf[uint8](200) {
+		t.Errorf("TestAverage: Mean(300 uint8s of 200) = %v, want 200", out)
+	}
+
+	if out := Mean(Map(func(int) int8 { return 100 }, Range(0, 300))); out != OptionOf[int8](100) {
+		t.Errorf("TestAverage: Mean(300 int8s of 100) = %v, want 100", out)
+	}
+
+	if out := Mean(Map(func(int) uint8 { return 0 }, Range(0, 256))); out != OptionOf[uint8](0) {
+		t.Errorf("TestAverage: Mean(256 uint8s of 0) = %v, want 0", out)
+	}
+
+	f32 := Map(func(int) float32 { return 2 }, Range(0, 1<<24+10))
+	if out := Mean(f32); out != OptionOf[float32](2) {
+		t.Errorf("TestAverage: Mean(2^24+10 float32s of 2) = %v, want 2", out)
+	}
+
+	type celsius int8
+
+	if out := Mean(Slice([]celsius{-20, -30})); out != OptionOf[celsius](-25) {
+		t.Errorf("TestAverage: Mean({-20, -30}) = %v, want -25", out)
+	}
+}
+
+func TestAllNone(t *testing.T) {
+	even := func(x int) bool { return x%2 == 0 }
+
+	tests := []struct {
+		name string
+		out  bool
+		want bool
+	}{
+		{"AllTrue", All(even, Slice([]int{2, 4, 6})), true},
+		{"AllFalse", All(even, Slice([]int{2, 3, 6})), false},
+		{"AllEmpty", All(even, Slice([]int{})), true},
+		{"NoneTrue", None(even, Slice([]int{1, 3, 5})), true},
+		{"NoneFalse", None(even, Slice([]int{1, 4, 5})), false},
+		{"NoneEmpty", None(even, Slice([]int{})), true},
+	}
+
+	for _, test := range tests {
+		if test.out != test.want {
+			t.Errorf("TestAllNone: %v = %v, want %v", test.name, test.out, test.want)
+		}
+	}
+}
+
+func TestFind(t *testing.T) {
+	xs := []int{1, 3, 4, 5, 6}
+	even := func(x int) bool { return x%2 == 0 }
+
+	if out := Find(even, Slice(xs)); out != OptionOf(4) {
+		t.Errorf("TestFind: Find(even, %v) = %v, want 4", xs, out)
+	}
+
+	if out := Find(even, Slice([]int{1, 3})); out.IsSome() {
+		t.Errorf("TestFind: Find(even, {1, 3}) = %v, want None", out)
+	}
+
+	if out := Position(even, Slice(xs)); out != 2 {
+		t.Errorf("TestFind: Position(even, %v) = %v, want 2", xs, out)
+	}
+
+	if out := Position(even, Slice([]int{1, 3})); out != -1 {
+		t.Errorf("TestFind: Position(even, {1, 3}) = %v, want -1", out)
+	}
+}
+
+func TestNth(t *testing.T) {
+	xs := []int{10, 20, 30}
+
+	for n, want := range xs {
+		if out := Nth(n, Slice(xs)); out != OptionOf(want) {
+			t.Errorf("TestNth: Nth(%v, %v) = %v, want %v", n, xs, out, want)
+		}
+	}
+
+	for _, n := range []int{-1, 3} {
+		if out := Nth(n, Slice(xs)); out.IsSome() {
+			t.Errorf("TestNth: Nth(%v, %v) = %v, want None", n, xs, out)
+		}
+	}
+}
+
+func TestFinishersShortCircuit(t *testing.T) {
+	even := func(x int) bool { return x%2 == 0 }
+
+	tests := []struct {
+		name   string
+		finish func(Iterator[int])
+	}{
+		{"All", func(iter Iterator[int]) { All(even, iter) }},
+		{"None", func(iter Iterator[int]) { None(even, iter) }},
+		{"Find", func(iter Iterator[int]) { Find(even, iter) }},
+		{"Position", func(iter Iterator[int]) { Position(even, iter) }},
+		{"Nth", func(iter Iterator[int]) { Nth(5, iter) }},
+	}
+
+	for _, test := range tests {
+		// would never return if it didn't stop consuming once the answer is known.
+		iter, exited := naturals(context.Background())
+		test.finish(iter)
+
+		select {
+		case <-exited:
+		default:
+			t.Errorf("TestFinishersShortCircuit: %v left its Iterator producing", test.name)
+		}
+	}
+}
+
+type grouped struct {
+	id   int
+	name string
+}
+
+func groupedValues() []grouped {
+	return []grouped{{1, "a"}, {2, "b"}, {1, "c"}, {3, "d"}, {1, "e"}}
+}
+
+func TestGroupBy(t *testing.T) {
+	id := func(g grouped) int { return g.id }
+
+	want := map[int][]grouped{
+		1: []grouped{{1, "a"}, {1, "c"}, {1, "e"}},
+		2: []grouped{{2, "b"}},
+		3: []grouped{{3, "d"}},
+	}
+
+	if out := GroupBy(id, Slice(groupedValues())); !reflect.DeepEqual(out, want) {
+		t.Errorf("TestGroupBy: GroupBy(id, ...) = %v, want %v", out, want)
+	}
+
+	if out := Collect(GroupByCollector(id), Slice(groupedValues())); !reflect.DeepEqual(out, want) {
+		t.Errorf("TestGroupBy: Collect(GroupByCollector(id), ...) = %v, want %v", out, want)
+	}
+}
+
+func TestCountBy(t *testing.T) {
+	want := map[int]int{1: 3, 2: 1, 3: 1}
+
+	out := CountBy(func(g grouped) int { return g.id }, Slice(groupedValues()))
+
+	if !reflect.DeepEqual(out, want) {
+		t.Errorf("TestCountBy: CountBy(id, ...) = %v, want %v", out, want)
+	}
+}
+
+func TestToMapWith(t *testing.T) {
+	pairs := Map(func(g grouped) KVPair[int, string] { return KVPair[int, string]{g.id, g.name} },
+		Slice(groupedValues()))
+
+	want := map[int]string{1: "a,c,e", 2: "b", 3: "d"}
+
+	out := ToMapWith(func(current, next string) string { return current + "," + next }, pairs)
+
+	if !reflect.DeepEqual(out, want) {
+		t.Errorf("TestToMapWith: out = %v, want %v", out, want)
+	}
+
+	boom := errors.New("boom")
+
+	_, err := ToMapWithErr(func(current, next int) int { return current + next },
+		Fail[KVPair[int, int]](boom))
+
+	if err != boom {
+		t.Errorf("TestToMapWith: ToMapWithErr err = %v, want %v", err, boom)
+	}
+}
@@ -10,8 +10,18 @@
 // common use-cases are supported by more convenient functions that produce iterators from different
 // values, transform the contents of iterators to produce new iterators, compose multiple iterators
 // into a single iterator, or produce a non-iterator value from an iterator.
+//
+// Every Iterator starts a goroutine of its own when it's created, as its values may be received
+// from Each. When an Iterator other than one created by Make (or Merge and the like) is passed to
+// another function in this package (e.g. Map, Filter or ToSlice), that function takes over
+// producing its values right away: they're pulled directly in the goroutine consuming them, and
+// the Iterator's goroutine exits, usually before computing anything. A chain of transformations
+// thus costs no goroutine switches per value, and only its last stage may compute a value ahead of
+// it being consumed.
 package giter
 
+import "sync"
+
 // An Iterator that produces 0 or more values
 //
 // To consume the iterator, range-loop over the Each channel.
@@ -32,22 +42,103 @@ package giter
 // Higher-level interfaces on Iterator consumption are expected to manage closing the Iterator when
 // appropriate; callers that simply pass an Iterator into an Iterator consumer should not be
 // required to manually call Close.
+//
+// An Iterator's producer may fail, in which case Each is closed early and Err reports why.
 type Iterator[T any] struct {
 	Each <-chan T
 
-	// stopChan is used to coordinate stopping of the Each producer goroutine: Close() sends
-	// a message on the channel, which the Each producer takes to mean it should stop producing
-	// and exit.
-	stopChan chan<- interface{}
+	// p coordinates with the goroutine producing to Each. It is shared by every copy of the
+	// Iterator.
+	p *producer
+
+	// pump, if set, allows pull-based consumers to take over the producer's puller, pulling values
+	// in their own goroutine rather than receiving them from Each.
+	pump *pump[T]
+
+	// size hints at how many values are produced to Each.
+	size SizeHint
+}
+
+// producer holds the state shared between an Iterator and the goroutine producing its values.
+type producer struct {
+	// stopChan is used to coordinate stopping of the Each producer goroutine: Close() closes the
+	// channel, which the Each producer takes to mean it should stop producing and exit. Since it
+	// is closed rather than sent to, the producer sees the signal wherever it next looks.
+	stopChan chan interface{}
+	stopOnce sync.Once
+
+	// done is closed once the producer goroutine has exited.
+	done chan interface{}
+
+	// err holds the error that ended production to Each, if any. It is written by the producer
+	// goroutine before Each is closed, so it may be read safely once Each has been drained.
+	err error
+
+	// cancel, if set, cancels the context given to a producer created via MakeContext.
+	cancel func()
+
+	// panicked holds what the producer goroutine panicked with, if it did. Like err, it's written
+	// before Each is closed. rethrown is set once it has been raised again by the consumer.
+	panicked *PanicError
+	rethrown int32
+}
+
+func (p *producer) stop() {
+	p.stopOnce.Do(func() {
+		if p.cancel != nil {
+			p.cancel()
+		}
+
+		close(p.stopChan)
+	})
+}
+
+// Err returns the error that ended production of the Iterator's values, or nil if the Iterator
+// produced all of its values (or was closed before producing them all).
+//
+// Err may only be called once Each has been closed or Close has returned. If the producer panicked,
+// Err panics with a PanicError.
+func (iter *Iterator[T]) Err() error {
+	if iter.p == nil {
+		return nil
+	}
+
+	iter.p.rethrow()
+
+	return iter.p.err
 }
 
 // Close stops production to Each and releases goroutines and any other resources held for producing
 // to this Iterator.
+//
+// Close is synchronous: it returns only once the producer has exited, which for the iterators in
+// this package means once every Iterator they consume has been closed too. No values are emitted
+// to Each after Close returns. A producer that doesn't watch its stop signal will thus block Close.
+//
+// If the producer panicked, Close panics with a PanicError.
 func (iter *Iterator[T]) Close() {
-	select {
-	case iter.stopChan <- nil:
-	default:
+	if iter.p == nil {
+		return
+	}
+
+	iter.p.stop()
+	<-iter.p.done
+
+	iter.p.rethrow()
+}
+
+// Wait blocks until the Iterator's producer has exited, either because it produced all of its
+// values or because the Iterator was closed, without itself stopping production.
+//
+// If the producer panicked, Wait panics with a PanicError.
+func (iter *Iterator[T]) Wait() {
+	if iter.p == nil {
+		return
 	}
+
+	<-iter.p.done
+
+	iter.p.rethrow()
 }
 
 // Make creates an Iterator via a given function that produces values.
@@ -58,58 +149,120 @@ func (iter *Iterator[T]) Close() {
 //
 // The function receives a function that is given two channels: one to which the function must
 // produce the values of the iterator, and the other which signals that no more values should be
-// produced and the implementation should return.
+// produced and the implementation should return. The stop channel is closed rather than sent to,
+// so it should be watched alongside every blocking operation the function performs.
 //
 // The resulting iterator will produce values in the order that they are produced by the passed
 // implementation function, in the order they were produced in.
 func Make[T any](impl func(values chan<- T, stop <-chan interface{})) (i Iterator[T]) {
+	return MakeErr(
+		func(values chan<- T, stop <-chan interface{}) error {
+			impl(values, stop)
+			return nil
+		})
+}
+
+// MakeErr creates an Iterator via a given function that produces values and may fail.
+//
+// It behaves as Make, except that the implementation function returns an error. A non-nil error
+// ends the Iterator, and is reported by its Err method once Each has been closed.
+func MakeErr[T any](impl func(values chan<- T, stop <-chan interface{}) error) (i Iterator[T]) {
+	return makeErr(impl, nil)
+}
+
+// makeErr implements MakeErr, additionally calling a given cancel function (if not nil) when the
+// Iterator is closed.
+func makeErr[T any](
+	impl func(values chan<- T, stop <-chan interface{}) error,
+	cancel func(),
+) (i Iterator[T]) {
 	values := make(chan T)
 
-	stopChan := make(chan interface{})
+	p := &producer{
+		stopChan: make(chan interface{}),
+		done:     make(chan interface{}),
+		cancel:   cancel,
+	}
 
 	go func() {
-		impl(values, stopChan)
-		close(values)
+		defer close(p.done)
+		defer close(values)
+		defer func() { p.capture(recover()) }()
+
+		p.err = impl(values, p.stopChan)
 	}()
 
 	return Iterator[T]{
-		Each:     values,
-		stopChan: stopChan,
+		Each: values,
+		p:    p,
+	}
+}
+
+// recv receives the next value of an Iterator, giving up if stop is signaled first.
+//
+// ok is false if no value was received, in which case stopped reports whether that's because stop
+// was signaled rather than because the Iterator is exhausted.
+func recv[T, S any](stop <-chan S, iter Iterator[T]) (x T, ok, stopped bool) {
+	select {
+	case x, ok := <-iter.Each:
+		return x, ok, false
+	case <-stop:
+		return x, false, true
 	}
 }
 
+// Fail returns an Iterator that emits no values and fails with the given error.
+func Fail[T any](err error) Iterator[T] {
+	return fromPuller(ExactSize(0), puller[T]{
+		next: func(_ <-chan interface{}) (x T, ok bool) {
+			return x, false
+		},
+		err: func() error { return err },
+	})
+}
+
 // Slice creates an iterator that emits the values of a given slice.
 //
 // Modification of the provided slice can impact the values produced, so caution is advised.
 //
 // The given slice will be held until all values are consumed or the iterator is closed.
 func Slice[T any](xs []T) (i Iterator[T]) {
-	return Make(
-		func(values chan<- T, stopChan <-chan interface{}) {
-			for _, x := range xs {
-				select {
-				case values <- x:
-				case <-stopChan:
-					return
-				}
+	next := 0
+
+	return fromPuller(ExactSize(len(xs)), puller[T]{
+		next: func(_ <-chan interface{}) (x T, ok bool) {
+			if next >= len(xs) {
+				return x, false
 			}
-		})
+
+			x = xs[next]
+			next++
+
+			return x, true
+		},
+		close: func() { xs = nil },
+	})
 }
 
 // SliceReversed creates an interator that emits the values of a given slice in reverse.
 //
 // Same caveats apply as in Slice.
 func SliceReversed[T any](xs []T) (i Iterator[T]) {
-	return Make(
-		func(values chan<- T, stopChan <-chan interface{}) {
-			for i, _ := range xs {
-				select {
-				case values <- xs[len(xs)-i-1]:
-				case <-stopChan:
-					return
-				}
+	next := len(xs) - 1
+
+	return fromPuller(ExactSize(len(xs)), puller[T]{
+		next: func(_ <-chan interface{}) (x T, ok bool) {
+			if next < 0 {
+				return x, false
 			}
-		})
+
+			x = xs[next]
+			next--
+
+			return x, true
+		},
+		close: func() { xs = nil },
+	})
 }
 
 // NeverShrink can be used to indicate to ConsumeSlice to never shrink the slice it's consuming.
@@ -128,45 +281,39 @@ func NeverShrink(_, _ int) bool { return false }
 //
 // A convenience shrink indicator function NeverShrink is provided to disable shrinking.
 func ConsumeSlice[T any](shrink func(l, c int) bool, xs []T) (i Iterator[T]) {
-	return Make(
-		func(values chan<- T, stopChan <-chan interface{}) {
-			var zero T
-			defer clear(&xs)
-
-		READ_XS:
-			for {
-				for i, x := range xs {
-					xs[i] = zero // zero out any pointers
+	next := 0
 
-					select {
-					case values <- x:
-					case <-stopChan:
-						return
-					}
+	return fromPuller(ExactSize(len(xs)), puller[T]{
+		next: func(_ <-chan interface{}) (x T, ok bool) {
+			if next >= len(xs) {
+				return x, false
+			}
 
-					// don't bother making an slice of cap 0 in the last
-					// iteration.
-					if i < len(xs)-1 && shrink(len(xs)-i-1, cap(xs)) {
-						xs = xs[i+1:]
+			var zero T
 
-						prime := make([]T, len(xs))
+			x = xs[next]
+			xs[next] = zero // zero out any pointers
+			next++
 
-						copy(prime, xs)
+			// don't bother making an slice of cap 0 after the last value.
+			if next < len(xs) && shrink(len(xs)-next, cap(xs)) {
+				prime := make([]T, len(xs)-next)
 
-						clear(&xs)
+				copy(prime, xs[next:])
 
-						xs = prime
+				clear(&xs)
 
-						// need to restart range so that we release xs and
-						// our indexing makes sense.
-						continue READ_XS
-					}
-				}
-
-				// we made it to the end of the range, thus we consumed everything.
-				break
+				xs = prime
+				next = 0
 			}
-		})
+
+			return x, true
+		},
+		close: func() {
+			clear(&xs)
+			next = 0
+		},
+	})
 }
 
 // MapKeys returns an iterator that emits the keys of a given map.
@@ -180,7 +327,7 @@ func MapKeys[K comparable, V any](m map[K]V) (i Iterator[K]) {
 					return
 				}
 			}
-		})
+		}).sized(ExactSize(len(m)))
 }
 
 // MapValues returns an Iterator that emits the values of a given map.
@@ -194,7 +341,7 @@ func MapValues[K comparable, V any](m map[K]V) (i Iterator[V]) {
 					return
 				}
 			}
-		})
+		}).sized(ExactSize(len(m)))
 }
 
 // KVPair holds the individual key-value pairs that represent a map.
@@ -220,7 +367,7 @@ func MapPairs[K comparable, V any](m map[K]V) (i Iterator[KVPair[K, V]]) {
 					break LOOP
 				}
 			}
-		})
+		}).sized(ExactSize(len(m)))
 }
 
 // One returns an Iterator emitting a single value.
@@ -228,12 +375,17 @@ func MapPairs[K comparable, V any](m map[K]V) (i Iterator[KVPair[K, V]]) {
 // Particularly useful for calling a function that receives an Iterator and one only wants to pass
 // a single value.
 func One[V any](x V) Iterator[V] {
-	return Make(
-		func(values chan<- V, stopChan <-chan interface{}) {
-			select {
-			case values <- x:
-			case <-stopChan:
-				return
+	done := false
+
+	return fromPuller(ExactSize(1), puller[V]{
+		next: func(_ <-chan interface{}) (v V, ok bool) {
+			if done {
+				return v, false
 			}
-		})
+
+			done = true
+
+			return x, true
+		},
+	})
 }
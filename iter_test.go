@@ -1,9 +1,12 @@
 package giter
 
 import (
+	"errors"
 	"reflect"
+	"runtime"
 	"sort"
 	"testing"
+	"time"
 )
 
 func TestSlice(t *testing.T) {
@@ -202,3 +205,126 @@ func TestOne(t *testing.T) {
 		t.Errorf("TestOne: out = %v, want %v", out, want)
 	}
 }
+
+func TestMakeErr(t *testing.T) {
+	want := []int{1, 2}
+	wantErr := errors.New("boom")
+
+	iter := MakeErr(
+		func(values chan<- int, stopChan <-chan interface{}) error {
+			for _, x := range want {
+				select {
+				case values <- x:
+				case <-stopChan:
+					return nil
+				}
+			}
+
+			return wantErr
+		})
+
+	defer iter.Close()
+
+	out := []int{}
+
+	for x := range iter.Each {
+		out = append(out, x)
+	}
+
+	if !reflect.DeepEqual(want, out) {
+		t.Errorf("TestMakeErr: out = %v, want %v", out, want)
+	}
+
+	if err := iter.Err(); err != wantErr {
+		t.Errorf("TestMakeErr: Err() = %v, want %v", err, wantErr)
+	}
+}
+
+func TestErrNil(t *testing.T) {
+	iter := Slice([]int{1, 2, 3})
+
+	for range iter.Each {
+	}
+
+	if err := iter.Err(); err != nil {
+		t.Errorf("TestErrNil: Err() = %v, want nil", err)
+	}
+}
+
+// checkGoroutines fails the test if the number of running goroutines doesn't settle back to at most
+// want within a short while.
+func checkGoroutines(t *testing.T, name string, want int) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+
+	for {
+		// goroutines may still be unwinding just after signaling that they're done.
+		n := runtime.NumGoroutine()
+		if n <= want {
+			return
+		}
+
+		if time.Now().After(deadline) {
+			t.Errorf("%v: %v goroutines running, want %v", name, n, want)
+			return
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestCloseReleasesGoroutines(t *testing.T) {
+	before := runtime.NumGoroutine()
+
+	iter := Chunk(2,
+		Map(func(x int) int { return 2 * x },
+			Filter(func(x int) bool { return x%2 == 0 },
+				Concat(Slice([]int{1, 2, 3, 4}), Range(0, 100)))))
+
+	<-iter.Each
+	iter.Close()
+
+	checkGoroutines(t, "TestCloseReleasesGoroutines", before)
+}
+
+func TestCloseBlockedUpstream(t *testing.T) {
+	exited := make(chan interface{})
+
+	// emits nothing until told to stop, so that Map is blocked receiving from it when closed.
+	stuck := Make(
+		func(_ chan<- int, stopChan <-chan interface{}) {
+			defer close(exited)
+			<-stopChan
+		})
+
+	iter := Map(func(x int) int { return x }, stuck)
+	iter.Close()
+
+	select {
+	case <-exited:
+	default:
+		t.Errorf("TestCloseBlockedUpstream: upstream producer still running after Close")
+	}
+
+	for range iter.Each {
+		t.Errorf("TestCloseBlockedUpstream: value emitted after Close")
+	}
+}
+
+func TestWait(t *testing.T) {
+	before := runtime.NumGoroutine()
+
+	iter := Map(func(x int) int { return x }, Slice([]int{1, 2, 3}))
+
+	for range iter.Each {
+	}
+
+	iter.Wait()
+
+	// closing after exhaustion, and repeatedly, is harmless.
+	iter.Close()
+	iter.Close()
+
+	checkGoroutines(t, "TestWait", before)
+}
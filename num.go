@@ -1,48 +1,273 @@
 package giter
 
+import (
+	"errors"
+	"math"
+)
+
+// Integer is a constraint permitting any integer type, including named types based on them.
+type Integer interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr
+}
+
+// Float is a constraint permitting any floating point type.
+type Float interface {
+	~float32 | ~float64
+}
+
+// Complex is a constraint permitting any complex number type.
+type Complex interface {
+	~complex64 | ~complex128
+}
+
+// Real is a constraint permitting any integer or floating point type: those numbers that can be
+// ordered, and so can be ranged over.
+type Real interface {
+	Integer | Float
+}
+
+// Ordered is a constraint permitting any type that can be ordered with <: numbers other than
+// complex ones, and strings.
+type Ordered interface {
+	Real | ~string
+}
+
+// Number is a constraint permitting any numeric type.
+type Number interface {
+	Integer | Float | Complex
+}
+
 // Sum consumes an Iterator of numbers and returns their sum (or zero, if no
 // values were emitted).
-func Sum[T int | int32 | int64 | float32 | float64](iter Iterator[T]) T {
+func Sum[T Number](iter Iterator[T]) T {
 	return Fold(0, func(x, y T) T { return x + y }, iter)
 }
 
 // Prod consumes an Iterator of numbers and returns their product (or one, if
 // no values were emitted).
-func Prod[T int | int32 | int64 | float32 | float64](iter Iterator[T]) T {
+func Prod[T Number](iter Iterator[T]) T {
 	return Fold(1, func(x, y T) T { return x * y }, iter)
 }
 
+// RunningSum returns an Iterator emitting the sum of the numbers emitted by the given Iterator so
+// far, after each of them.
+//
+// If the given Iterator fails, so does the returned one.
+func RunningSum[T Number](iter Iterator[T]) Iterator[T] {
+	return Scan(0, func(x, y T) T { return x + y }, iter)
+}
+
+// RunningProd returns an Iterator emitting the product of the numbers emitted by the given
+// Iterator so far, after each of them.
+//
+// If the given Iterator fails, so does the returned one.
+func RunningProd[T Number](iter Iterator[T]) Iterator[T] {
+	return Scan(1, func(x, y T) T { return x * y }, iter)
+}
+
 // Range returns an iterator emitting numeric values over a given range.
 //
-// The given range is half-open, inclusive on the left and exclusive on the right.
-//
-// Values are emitted in steps of 1; odd floating point values may cause unexpected results.
-func Range[T int | int32 | int64 | float32 | float64](from, until T) Iterator[T] {
-	return Make(
-		func(out chan<- T, stopChan <-chan interface{}) {
-			for v := from; v < until; v++ {
-				select {
-				case out <- v:
-				case <-stopChan:
-					return
-				}
-			}
-		})
+// The given range is half-open, inclusive on the left and exclusive on the right. Values ascend
+// in steps of 1, so the range is empty if from >= until; use RangeDown to descend.
+//
+// Odd floating point values may cause unexpected results.
+func Range[T Real](from, until T) Iterator[T] {
+	return ranged(from, until, 1, false, false)
+}
+
+// RangeInclusive is as Range, but includes until if the steps land on it.
+func RangeInclusive[T Real](from, until T) Iterator[T] {
+	return ranged(from, until, 1, false, true)
+}
+
+// RangeDown returns an iterator emitting numeric values over a given range in descending order.
+//
+// The given range is half-open, inclusive on the left and exclusive on the right: RangeDown(5, 0)
+// emits 5, 4, 3, 2 and 1. Values descend in steps of 1, so the range is empty if from <= until.
+//
+// Unlike RangeBy with a negative step, RangeDown works for unsigned types too.
+func RangeDown[T Real](from, until T) Iterator[T] {
+	return ranged(from, until, 1, true, false)
 }
 
+// RangeDownInclusive is as RangeDown, but includes until if the steps land on it.
+func RangeDownInclusive[T Real](from, until T) Iterator[T] {
+	return ranged(from, until, 1, true, true)
+}
+
+// ErrZeroStep is the error with which ranges fail when given a step of zero.
+var ErrZeroStep = errors.New("giter: range step must not be zero")
+
 // RangeBy returns an iterator emitting numeric values over a given range with
 // a given step.
 //
-// The given range is half-open, inclusive on the left and exclusive on the right.
-func RangeBy[T int | int32 | int64 | float32 | float64](from, until, by T) Iterator[T] {
-	return Make(
-		func(out chan<- T, stopChan <-chan interface{}) {
-			for v := from; v < until; v += by {
-				select {
-				case out <- v:
-				case <-stopChan:
-					return
+// The given range is half-open, inclusive on the left and exclusive on the right. A positive step
+// ascends from from towards until, and a negative one descends; if until lies the other way, the
+// range is empty. A zero step would never reach until, so the returned Iterator fails with
+// ErrZeroStep instead.
+func RangeBy[T Real](from, until, by T) Iterator[T] {
+	if by == 0 {
+		return Fail[T](ErrZeroStep)
+	}
+
+	return ranged(from, until, by, by < 0, false)
+}
+
+// RangeByInclusive is as RangeBy, but includes until if the steps land on it.
+func RangeByInclusive[T Real](from, until, by T) Iterator[T] {
+	if by == 0 {
+		return Fail[T](ErrZeroStep)
+	}
+
+	return ranged(from, until, by, by < 0, true)
+}
+
+// ranged implements the range functions: it returns an iterator emitting values from from towards
+// bound, ascending or descending (if down is set) in steps the size of by, whatever its sign.
+// bound itself is emitted if inclusive is set and the steps land on it.
+func ranged[T Real](from, bound, by T, down, inclusive bool) Iterator[T] {
+	if T(1)/T(2) != 0 {
+		step := by
+		if step < 0 {
+			step = -step
+		}
+
+		return fractionalRanged(from, bound, step, down, inclusive)
+	}
+
+	step := uint64(by)
+	if by < 0 {
+		// converting by sign-extends it, so this is its magnitude.
+		step = -step
+	}
+
+	return integerRanged(from, bound, step, down, inclusive)
+}
+
+// integerRanged implements ranged for integers, in steps of a given size.
+//
+// Ranges never step past bound, so that ranges ending at the limits of T don't overflow. They're
+// measured and stepped in uint64, whose wrapping arithmetic agrees with T's but which can hold the
+// distance between any two values of T, and the size of T's most negative value.
+func integerRanged[T Real](from, bound T, step uint64, down, inclusive bool) Iterator[T] {
+	remaining := func(v T) (uint64, bool) {
+		if down {
+			return uint64(v) - uint64(bound), v >= bound
+		}
+
+		return uint64(bound) - uint64(v), v <= bound
+	}
+
+	v := from
+	rem, within := remaining(v)
+	done := !within || (rem == 0 && !inclusive)
+
+	size := ExactSize(0)
+	if !done {
+		size = integerRangeSize(rem, step, inclusive)
+	}
+
+	return fromPuller(size, puller[T]{
+		next: func(_ <-chan interface{}) (x T, ok bool) {
+			if done {
+				return x, false
+			}
+
+			x = v
+
+			if rem < step || (rem == step && !inclusive) {
+				done = true
+			} else {
+				if down {
+					v = T(uint64(v) - step)
+				} else {
+					v = T(uint64(v) + step)
 				}
+
+				rem, _ = remaining(v)
+			}
+
+			return x, true
+		},
+	})
+}
+
+// fractionalRanged implements ranged for floating point numbers, in steps of a given size.
+//
+// Each value is computed as from plus a multiple of the step, rather than by adding up steps, so
+// that rounding errors don't accumulate: RangeByInclusive(0.0, 1.0, 0.1) ends on 1 rather than
+// just short of it.
+func fractionalRanged[T Real](from, bound, step T, down, inclusive bool) Iterator[T] {
+	within := func(v T) bool {
+		if v == bound {
+			return inclusive
+		} else if down {
+			return v > bound
+		}
+
+		return v < bound
+	}
+
+	size := ExactSize(0)
+	if within(from) {
+		if down {
+			size = fractionalRangeSize(from-bound, step)
+		} else {
+			size = fractionalRangeSize(bound-from, step)
+		}
+	}
+
+	n := 0
+	done := false
+
+	return fromPuller(size, puller[T]{
+		next: func(_ <-chan interface{}) (x T, ok bool) {
+			if done {
+				return x, false
+			}
+
+			v := from + T(n)*step
+			if down {
+				v = from - T(n)*step
+			}
+
+			if !within(v) {
+				done = true
+				return x, false
 			}
-		})
+
+			n++
+
+			return v, true
+		},
+	})
+}
+
+// fractionalRangeSize returns a hint of how many values a floating point range emits, given how
+// far its first value is from its bound and the size of its steps.
+func fractionalRangeSize[T Real](rem, step T) SizeHint {
+	// rounding may land the last value either side of the quotient below.
+	n := math.Floor(float64(rem) / float64(step))
+	if n+2 >= math.MaxInt {
+		return SizeHint{}
+	}
+
+	return AtMostSize(int(n) + 2)
+}
+
+// integerRangeSize returns how many values a nonempty integer range emits, given how far its first
+// value is from its bound and the size of its steps.
+func integerRangeSize(rem, step uint64, inclusive bool) SizeHint {
+	// rem is nonzero if the range is exclusive, so this can't underflow.
+	if !inclusive {
+		rem--
+	}
+
+	n := rem / step
+	if n >= math.MaxInt {
+		return SizeHint{}
+	}
+
+	return ExactSize(int(n) + 1)
 }
@@ -1,6 +1,7 @@
 package giter
 
 import (
+	"math"
 	"reflect"
 	"testing"
 )
@@ -57,6 +58,34 @@ func TestFpRange(t *testing.T) {
 	}
 }
 
+func TestFpRangeInclusive(t *testing.T) {
+	// adding up steps of 0.1 would fall just short of 1.
+	out := ToSlice(RangeByInclusive(0.0, 1.0, 0.1))
+
+	if len(out) != 11 || out[0] != 0 || out[10] != 1 {
+		t.Errorf("TestFpRangeInclusive: out = %v, want 11 values from 0 to 1", out)
+	}
+
+	out = ToSlice(RangeByInclusive(1.0, 0.0, -0.1))
+
+	if len(out) != 11 || out[0] != 1 || out[10] != 0 {
+		t.Errorf("TestFpRangeInclusive: out = %v, want 11 values from 1 to 0", out)
+	}
+
+	out = ToSlice(RangeBy(0.0, 1.0, 0.1))
+
+	if len(out) != 10 || out[9] >= 1 {
+		t.Errorf("TestFpRangeInclusive: exclusive out = %v, want 10 values below 1", out)
+	}
+
+	iter := RangeByInclusive(0.0, 1.0, 0.1)
+	defer iter.Close()
+
+	if size := iter.Size(); size.Kind != SizeAtMost || size.N < 11 {
+		t.Errorf("TestFpRangeInclusive: Size() = %v, want at most >= 11", size)
+	}
+}
+
 func TestRangeBy(t *testing.T) {
 	want := []int{10, 20, 30, 40, 50}
 
@@ -66,3 +95,166 @@ func TestRangeBy(t *testing.T) {
 		t.Errorf("TestRangeBy: out = %v, want %v", out, want)
 	}
 }
+
+func TestRangeDirections(t *testing.T) {
+	tests := []struct {
+		name string
+		iter Iterator[int]
+		want []int
+	}{
+		{"RangeEmpty", Range(6, 1), []int{}},
+		{"RangeInclusive", RangeInclusive(1, 5), []int{1, 2, 3, 4, 5}},
+		{"RangeDown", RangeDown(5, 0), []int{5, 4, 3, 2, 1}},
+		{"RangeDownEmpty", RangeDown(1, 6), []int{}},
+		{"RangeDownInclusive", RangeDownInclusive(5, 1), []int{5, 4, 3, 2, 1}},
+		{"RangeByNegative", RangeBy(50, 5, -10), []int{50, 40, 30, 20, 10}},
+		{"RangeByWrongWay", RangeBy(0, 10, -1), []int{}},
+		{"RangeByInclusive", RangeByInclusive(10, 50, 10), []int{10, 20, 30, 40, 50}},
+		{"RangeByInclusiveMissed", RangeByInclusive(10, 55, 10), []int{10, 20, 30, 40, 50}},
+		{"RangeByInclusiveNegative", RangeByInclusive(0, -6, -3), []int{0, -3, -6}},
+		{"RangeInclusiveSingle", RangeInclusive(3, 3), []int{3}},
+	}
+
+	for _, test := range tests {
+		out, err := ToSliceErr(test.iter)
+
+		if err != nil || !reflect.DeepEqual(test.want, out) {
+			t.Errorf("TestRangeDirections: %s: out = %v, %v, want %v", test.name, out, err,
+				test.want)
+		}
+	}
+}
+
+func TestRangeLimits(t *testing.T) {
+	// ranges ending at the limits of their type mustn't overflow and start over.
+	want := []int32{math.MaxInt32 - 2, math.MaxInt32 - 1, math.MaxInt32}
+
+	out := ToSlice(RangeInclusive[int32](math.MaxInt32-2, math.MaxInt32))
+
+	if !reflect.DeepEqual(want, out) {
+		t.Errorf("TestRangeLimits: out = %v, want %v", out, want)
+	}
+
+	want = []int32{math.MinInt32 + 4, math.MinInt32 + 1}
+
+	out = ToSlice(RangeByInclusive[int32](math.MinInt32+4, math.MinInt32, -3))
+
+	if !reflect.DeepEqual(want, out) {
+		t.Errorf("TestRangeLimits: out = %v, want %v", out, want)
+	}
+}
+
+func TestRangeWideSpans(t *testing.T) {
+	// ranges spanning more than the maximum of their type mustn't overflow measuring their size.
+	iter := Range[int8](-100, 100)
+
+	if size := iter.Size(); size != ExactSize(200) {
+		t.Errorf("TestRangeWideSpans: Size() = %v, want %v", size, ExactSize(200))
+	}
+
+	if out := ToSlice(iter); len(out) != 200 || out[0] != -100 || out[199] != 99 {
+		t.Errorf("TestRangeWideSpans: out = %v, want -100 through 99", out)
+	}
+
+	if out := Count(RangeInclusive[int8](math.MinInt8, math.MaxInt8)); out != 256 {
+		t.Errorf("TestRangeWideSpans: int8 count = %v, want 256", out)
+	}
+
+	if out := Count(RangeDownInclusive[int8](math.MaxInt8, math.MinInt8)); out != 256 {
+		t.Errorf("TestRangeWideSpans: int8 count down = %v, want 256", out)
+	}
+
+	want32 := []int32{-2e9, -1e9, 0, 1e9}
+
+	if out := ToSlice(RangeBy[int32](-2e9, 2e9, 1e9)); !reflect.DeepEqual(want32, out) {
+		t.Errorf("TestRangeWideSpans: out = %v, want %v", out, want32)
+	}
+
+	want64 := []int64{math.MinInt64, math.MinInt64 / 2, 0, math.MaxInt64/2 + 1}
+
+	out64 := ToSlice(RangeBy[int64](math.MinInt64, math.MaxInt64, math.MaxInt64/2+1))
+	if !reflect.DeepEqual(want64, out64) {
+		t.Errorf("TestRangeWideSpans: out = %v, want %v", out64, want64)
+	}
+
+	if size := Range[int64](math.MinInt64, math.MaxInt64).Size(); size.Known() {
+		t.Errorf("TestRangeWideSpans: int64 Size() = %v, want unknown", size)
+	}
+}
+
+func TestRangeMostNegativeStep(t *testing.T) {
+	// the most negative step can't be negated without overflowing.
+	tests := []struct {
+		name string
+		iter Iterator[int8]
+		want []int8
+	}{
+		{"RangeBy", RangeBy[int8](0, -100, math.MinInt8), []int8{0}},
+		{"RangeByReaching", RangeBy[int8](math.MaxInt8, math.MinInt8, math.MinInt8), []int8{127, -1}},
+		{"RangeByInclusive", RangeByInclusive[int8](0, math.MinInt8, math.MinInt8), []int8{0, -128}},
+		{"RangeByWrongWay", RangeBy[int8](0, 100, math.MinInt8), []int8{}},
+	}
+
+	for _, test := range tests {
+		out := ToSlice(test.iter)
+
+		if !reflect.DeepEqual(test.want, out) {
+			t.Errorf("TestRangeMostNegativeStep: %s: out = %v, want %v", test.name, out, test.want)
+		}
+	}
+}
+
+func TestRangeZeroStep(t *testing.T) {
+	for _, iter := range []Iterator[int]{RangeBy(0, 10, 0), RangeByInclusive(0, 10, 0)} {
+		out, err := ToSliceErr(iter)
+
+		if len(out) != 0 || err != ErrZeroStep {
+			t.Errorf("TestRangeZeroStep: out = %v, %v, want [], %v", out, err, ErrZeroStep)
+		}
+	}
+}
+
+type cents int64
+
+func TestNumberTypes(t *testing.T) {
+	if out := Sum(Slice([]cents{100, 250})); out != 350 {
+		t.Errorf("TestNumberTypes: cents Sum = %v, want 350", out)
+	}
+
+	if out := Sum(Range[uint64](1, 5)); out != 10 {
+		t.Errorf("TestNumberTypes: uint64 Sum = %v, want 10", out)
+	}
+
+	if out := Prod(Slice([]complex128{1 + 1i, 1 - 1i})); out != 2 {
+		t.Errorf("TestNumberTypes: complex128 Prod = %v, want 2", out)
+	}
+
+	// descending to the bottom of an unsigned type mustn't wrap around.
+	wantDown := []uint8{2, 1, 0}
+
+	if out := ToSlice(RangeDownInclusive[uint8](2, 0)); !reflect.DeepEqual(wantDown, out) {
+		t.Errorf("TestNumberTypes: uint8 RangeDownInclusive = %v, want %v", out, wantDown)
+	}
+
+	wantUp := []int8{121, 124, 127}
+
+	if out := ToSlice(RangeByInclusive[int8](121, 127, 3)); !reflect.DeepEqual(wantUp, out) {
+		t.Errorf("TestNumberTypes: int8 RangeByInclusive = %v, want %v", out, wantUp)
+	}
+}
+
+func TestRunningSum(t *testing.T) {
+	xs := []int{1, 2, 3, 4, 5}
+
+	want := []int{1, 3, 6, 10, 15}
+
+	if out := ToSlice(RunningSum(Slice(xs))); !reflect.DeepEqual(want, out) {
+		t.Errorf("TestRunningSum: out = %v, want %v", out, want)
+	}
+
+	want = []int{1, 2, 6, 24, 120}
+
+	if out := ToSlice(RunningProd(Slice(xs))); !reflect.DeepEqual(want, out) {
+		t.Errorf("TestRunningSum: RunningProd out = %v, want %v", out, want)
+	}
+}
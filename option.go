@@ -0,0 +1,55 @@
+package giter
+
+import "fmt"
+
+// An Option holds either a value or nothing, as returned by finishers such as FirstOption or Min
+// for iterators that emit no values.
+//
+// Unlike a pointer, an Option tells "no value" apart from a zero value without allocating. The zero
+// Option holds nothing; OptionOf makes one holding a value.
+type Option[T any] struct {
+	value T
+	ok    bool
+}
+
+// OptionOf returns an Option holding the given value.
+func OptionOf[T any](x T) Option[T] {
+	return Option[T]{x, true}
+}
+
+// Get returns the value held by the Option and true, or the zero value and false if it holds
+// nothing.
+func (o Option[T]) Get() (T, bool) {
+	return o.value, o.ok
+}
+
+// OrElse returns the value held by the Option, or the given value if it holds nothing.
+func (o Option[T]) OrElse(x T) T {
+	if !o.ok {
+		return x
+	}
+
+	return o.value
+}
+
+// IsSome returns true if the Option holds a value.
+func (o Option[T]) IsSome() bool {
+	return o.ok
+}
+
+// Ptr returns a pointer to a copy of the value held by the Option, or nil if it holds nothing.
+func (o Option[T]) Ptr() *T {
+	if !o.ok {
+		return nil
+	}
+
+	return &o.value
+}
+
+func (o Option[T]) String() string {
+	if !o.ok {
+		return "None"
+	}
+
+	return fmt.Sprintf("Some(%v)", o.value)
+}
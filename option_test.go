@@ -0,0 +1,57 @@
+package giter
+
+import "testing"
+
+func TestOption(t *testing.T) {
+	some, none := OptionOf(0), Option[int]{}
+
+	if x, ok := some.Get(); !ok || x != 0 || !some.IsSome() {
+		t.Errorf("TestOption: OptionOf(0).Get() = %v, %v, want 0, true", x, ok)
+	}
+
+	if x, ok := none.Get(); ok || none.IsSome() {
+		t.Errorf("TestOption: None.Get() = %v, %v, want 0, false", x, ok)
+	}
+
+	if x := some.OrElse(1); x != 0 {
+		t.Errorf("TestOption: OptionOf(0).OrElse(1) = %v, want 0", x)
+	}
+
+	if x := none.OrElse(1); x != 1 {
+		t.Errorf("TestOption: None.OrElse(1) = %v, want 1", x)
+	}
+
+	if p := some.Ptr(); p == nil || *p != 0 {
+		t.Errorf("TestOption: OptionOf(0).Ptr() = %v, want pointer to 0", p)
+	}
+
+	if p := none.Ptr(); p != nil {
+		t.Errorf("TestOption: None.Ptr() = %v, want nil", p)
+	}
+
+	if s := some.String(); s != "Some(0)" {
+		t.Errorf("TestOption: OptionOf(0).String() = %q, want Some(0)", s)
+	}
+
+	if s := none.String(); s != "None" {
+		t.Errorf("TestOption: None.String() = %q, want None", s)
+	}
+}
+
+func TestLastAllocs(t *testing.T) {
+	xs := make([]int, 100)
+
+	// pulling from a Slice doesn't allocate per value, so neither should keeping the last one.
+	allocs := testing.AllocsPerRun(10, func() { LastOption(Slice(xs)) })
+
+	if perValue := allocs / float64(len(xs)); perValue >= 0.5 {
+		t.Errorf("TestLastAllocs: LastOption made %v allocations per value, want none", perValue)
+	}
+
+	// nor should Last, which only allocates the pointer to the value it returns.
+	allocs = testing.AllocsPerRun(10, func() { Last(Slice(xs)) })
+
+	if perValue := allocs / float64(len(xs)); perValue >= 0.5 {
+		t.Errorf("TestLastAllocs: Last made %v allocations per value, want none", perValue)
+	}
+}
@@ -0,0 +1,72 @@
+package giter
+
+import (
+	"fmt"
+	"runtime/debug"
+	"sync/atomic"
+)
+
+// A PanicError is what an Iterator's consumer panics with when the Iterator's producer panicked.
+//
+// Producers run in goroutines of their own (or of some other consumer), where a panic would crash
+// the program with a stack trace pointing nowhere near the code consuming the Iterator. Instead,
+// the panic is recovered there, the producer's upstream iterators are closed, and the consumer
+// panics with a PanicError in its stead: when it next pulls a value, or for a consumer ranging over
+// Each, when it calls Close, Err or Wait.
+//
+// A panic is only raised again once, in whichever of those calls first observes it. Producers that
+// happen to run in the consumer's goroutine (see the package documentation) have their panics
+// wrapped in a PanicError too, so that the consumer sees the same whichever goroutine produced.
+type PanicError struct {
+	// Value is the value the producer panicked with.
+	Value interface{}
+
+	// Stack is the stack trace of the producer goroutine at the time it panicked.
+	Stack []byte
+}
+
+func (p *PanicError) Error() string {
+	return fmt.Sprintf("giter: producer panicked: %v\n\n%s", p.Value, p.Stack)
+}
+
+// Unwrap returns the value the producer panicked with, if it's an error.
+func (p *PanicError) Unwrap() error {
+	if err, ok := p.Value.(error); ok {
+		return err
+	}
+
+	return nil
+}
+
+// capture records a value recovered from a panic in the producer goroutine, if any, returning true
+// if there was one.
+//
+// It must be called with the result of recover() in a function deferred by the producer goroutine.
+func (p *producer) capture(v interface{}) bool {
+	if v == nil {
+		return false
+	}
+
+	p.panicked = newPanicError(v)
+
+	return true
+}
+
+// newPanicError returns a PanicError for a value recovered from a panic, which must be called from
+// the function that recovered it to capture the right stack.
+func newPanicError(v interface{}) *PanicError {
+	// a panic passing through from some upstream producer already says where it came from.
+	if pe, ok := v.(*PanicError); ok {
+		return pe
+	}
+
+	return &PanicError{v, debug.Stack()}
+}
+
+// rethrow panics with what the producer goroutine panicked with, if it did and hasn't been raised
+// again already. It may only be called once the producer goroutine has exited.
+func (p *producer) rethrow() {
+	if p.panicked != nil && atomic.CompareAndSwapInt32(&p.rethrown, 0, 1) {
+		panic(p.panicked)
+	}
+}
@@ -0,0 +1,114 @@
+package giter
+
+import (
+	"runtime"
+	"testing"
+)
+
+// recovered calls f, returning what it panicked with, if anything.
+func recovered(f func()) (v interface{}) {
+	defer func() {
+		v = recover()
+	}()
+
+	f()
+
+	return nil
+}
+
+// isBoom returns true if v is a PanicError of a producer panicking with "boom".
+func isBoom(v interface{}) bool {
+	pe, ok := v.(*PanicError)
+	return ok && pe.Value == "boom" && len(pe.Stack) > 0
+}
+
+func boomAt(n int) func(int) int {
+	return func(x int) int {
+		if x == n {
+			panic("boom")
+		}
+
+		return x
+	}
+}
+
+func TestPanicPulled(t *testing.T) {
+	// whether the first value is computed by the Map's pump goroutine or pulled by ToSlice, the
+	// panic looks the same.
+	for _, n := range []int{0, 1, 2} {
+		v := recovered(func() { _ = ToSlice(Map(boomAt(n), Slice([]int{0, 1, 2}))) })
+
+		if !isBoom(v) {
+			t.Errorf("TestPanicPulled: ToSlice(Map(boomAt(%v), ...)) panicked with %v, "+
+				"want PanicError of boom", n, v)
+		}
+	}
+}
+
+func TestPanicEach(t *testing.T) {
+	iter := Map(boomAt(1), Slice([]int{1, 2, 3}))
+
+	for range iter.Each {
+		t.Errorf("TestPanicEach: value emitted after panic")
+	}
+
+	defer func() {
+		pe, ok := recover().(*PanicError)
+
+		if !ok || pe.Value != "boom" || len(pe.Stack) == 0 {
+			t.Errorf("TestPanicEach: Close panicked with %v, want PanicError of boom", pe)
+		}
+
+		// raised only once.
+		iter.Close()
+	}()
+
+	iter.Close()
+
+	t.Errorf("TestPanicEach: Close didn't panic")
+}
+
+func TestPanicMake(t *testing.T) {
+	iter := Make(
+		func(values chan<- int, stopChan <-chan interface{}) {
+			values <- 1
+			panic("boom")
+		})
+
+	v := recovered(func() { _ = Sum(Map(func(x int) int { return x }, iter)) })
+
+	if !isBoom(v) {
+		t.Errorf("TestPanicMake: Sum panicked with %v, want PanicError of boom", v)
+	}
+}
+
+func TestPanicTeardown(t *testing.T) {
+	before := runtime.NumGoroutine()
+
+	exited := make(chan interface{})
+
+	src := Make(
+		func(values chan<- int, stopChan <-chan interface{}) {
+			defer close(exited)
+
+			for i := 0; ; i++ {
+				select {
+				case values <- i:
+				case <-stopChan:
+					return
+				}
+			}
+		})
+
+	v := recovered(func() {
+		_ = ToSlice(Merge(Map(boomAt(5), src), Range(0, 1000)))
+	})
+
+	if !isBoom(v) {
+		t.Errorf("TestPanicTeardown: ToSlice panicked with %v, want PanicError of boom", v)
+	}
+
+	<-exited
+
+	checkGoroutines(t, "TestPanicTeardown", before)
+}
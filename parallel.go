@@ -0,0 +1,221 @@
+package giter
+
+import "sync"
+
+// ParallelMap is as Map, but calls the given function on up to the given number of goroutines at
+// once, while still emitting the results in the order of the values they were mapped from.
+//
+// Values are pulled from the given Iterator only as workers become free and results are emitted,
+// so that no more than a couple of values per worker are held at once however slowly the returned
+// Iterator is consumed. A result that takes long to compute holds up the results after it.
+//
+// Closing the returned Iterator stops the workers and closes the given Iterator. If the given
+// Iterator fails, so does the returned one, after emitting the results of the values before the
+// failure.
+func ParallelMap[T, TP any](workers int, f func(T) TP, iter Iterator[T]) Iterator[TP] {
+	return parallelMap(workers, f, iter, true)
+}
+
+// UnorderedParallelMap is as ParallelMap, but emits each result as soon as it's ready, regardless
+// of the order of the values they were mapped from.
+func UnorderedParallelMap[T, TP any](workers int, f func(T) TP, iter Iterator[T]) Iterator[TP] {
+	return parallelMap(workers, f, iter, false)
+}
+
+// parallelJob is a value to be mapped by a worker of a parallel map.
+type parallelJob[T, TP any] struct {
+	x T
+
+	// slot receives the result, for ordered maps.
+	slot chan parallelResult[TP]
+}
+
+// parallelResult is the result of mapping a value, or what the mapping function panicked with.
+type parallelResult[T any] struct {
+	x        T
+	panicked *PanicError
+}
+
+// apply calls f on a job's value, recovering any panic into the result.
+func (j parallelJob[T, TP]) apply(f func(T) TP) (r parallelResult[TP]) {
+	// workers aren't producer goroutines, so have to pass on any panic to the one they work for.
+	defer func() {
+		if v := recover(); v != nil {
+			r.panicked = newPanicError(v)
+		}
+	}()
+
+	r.x = f(j.x)
+
+	return r
+}
+
+// parallelMap implements ParallelMap and UnorderedParallelMap.
+//
+// The producer goroutine starts a feeder goroutine, which pulls values from the given Iterator and
+// hands them over to the worker goroutines as jobs. For ordered maps, the feeder also queues each
+// job's result slot, from which the producer emits the results in order; otherwise, the workers
+// send their results straight to the producer.
+func parallelMap[T, TP any](
+	workers int,
+	f func(T) TP,
+	iter Iterator[T],
+	ordered bool,
+) Iterator[TP] {
+	if workers < 1 {
+		workers = 1
+	}
+
+	return MakeErr(
+		func(out chan<- TP, stop <-chan interface{}) error {
+			// quit stops the feeder and workers once we return, whether we were stopped, are
+			// done or panicked; we don't return until they've exited, and thus until the given
+			// Iterator has been closed.
+			quit := make(chan interface{})
+
+			var wg sync.WaitGroup
+			defer wg.Wait()
+			defer close(quit)
+
+			jobs := make(chan parallelJob[T, TP])
+
+			// the result slots of the jobs handed out, in order; this bounds how far ahead of
+			// the results we emit the workers may get.
+			slots := make(chan chan parallelResult[TP], workers)
+
+			// what ended the feeder, written before it closes jobs and slots.
+			var feedErr error
+			var feedPanicked *PanicError
+
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer close(jobs)
+				defer close(slots)
+				defer func() {
+					if v := recover(); v != nil {
+						feedPanicked = newPanicError(v)
+					}
+				}()
+
+				up := pull(iter)
+				defer up.Close()
+
+				for {
+					x, ok := up.next(quit)
+					if !ok {
+						if !stopped(quit) {
+							feedErr = up.Err()
+						}
+
+						return
+					}
+
+					job := parallelJob[T, TP]{x: x}
+
+					if ordered {
+						job.slot = make(chan parallelResult[TP], 1)
+
+						select {
+						case slots <- job.slot:
+						case <-quit:
+							return
+						}
+					}
+
+					select {
+					case jobs <- job:
+					case <-quit:
+						return
+					}
+				}
+			}()
+
+			results := make(chan parallelResult[TP])
+
+			var working sync.WaitGroup
+
+			for i := 0; i < workers; i++ {
+				wg.Add(1)
+				working.Add(1)
+
+				go func() {
+					defer wg.Done()
+					defer working.Done()
+
+					for job := range jobs {
+						if stopped(quit) {
+							return
+						}
+
+						r := job.apply(f)
+
+						if ordered {
+							// slots have room for their result, so this never blocks.
+							job.slot <- r
+							continue
+						}
+
+						select {
+						case results <- r:
+						case <-quit:
+							return
+						}
+					}
+				}()
+			}
+
+			if ordered {
+				for slot := range slots {
+					var r parallelResult[TP]
+
+					select {
+					case r = <-slot:
+					case <-stop:
+						return nil
+					}
+
+					if !emitParallel(out, stop, r) {
+						return nil
+					}
+				}
+			} else {
+				// the workers exit once the feeder has closed jobs, at which point so are all
+				// results in.
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+
+					working.Wait()
+					close(results)
+				}()
+
+				for r := range results {
+					if !emitParallel(out, stop, r) {
+						return nil
+					}
+				}
+			}
+
+			if feedPanicked != nil {
+				panic(feedPanicked)
+			}
+
+			return feedErr
+		}).sized(iter.size)
+}
+
+// emitParallel sends the value of a result to out, returning false if stop was signaled first. If
+// computing the result panicked, it panics with the same.
+func emitParallel[T any](out chan<- T, stop <-chan interface{}, r parallelResult[T]) bool {
+	if r.panicked != nil {
+		panic(r.panicked)
+	}
+
+	select {
+	case out <- r.x:
+		return true
+	case <-stop:
+		return false
+	}
+}
@@ -0,0 +1,148 @@
+package giter
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"runtime"
+	"sort"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// jittered returns a function doubling its argument after sleeping a little while, varying by
+// argument so that results are ready out of order, and recording how many calls run at once.
+func jittered(running, most *int32) func(int) int {
+	return func(x int) int {
+		n := atomic.AddInt32(running, 1)
+		defer atomic.AddInt32(running, -1)
+
+		for {
+			m := atomic.LoadInt32(most)
+			if n <= m || atomic.CompareAndSwapInt32(most, m, n) {
+				break
+			}
+		}
+
+		time.Sleep(time.Duration(x%4) * time.Millisecond)
+
+		return 2 * x
+	}
+}
+
+func TestParallelMap(t *testing.T) {
+	before := runtime.NumGoroutine()
+
+	var running, most int32
+
+	want := ToSlice(Map(func(x int) int { return 2 * x }, Range(0, 50)))
+
+	out := ToSlice(ParallelMap(4, jittered(&running, &most), Range(0, 50)))
+
+	if !reflect.DeepEqual(out, want) {
+		t.Errorf("TestParallelMap: out = %v, want %v", out, want)
+	}
+
+	if most < 2 || most > 4 {
+		t.Errorf("TestParallelMap: %v calls ran at once, want 2 to 4", most)
+	}
+
+	checkGoroutines(t, "TestParallelMap", before)
+}
+
+func TestUnorderedParallelMap(t *testing.T) {
+	before := runtime.NumGoroutine()
+
+	var running, most int32
+
+	want := ToSlice(Map(func(x int) int { return 2 * x }, Range(0, 50)))
+
+	out := ToSlice(UnorderedParallelMap(4, jittered(&running, &most), Range(0, 50)))
+	sort.Ints(out)
+
+	if !reflect.DeepEqual(out, want) {
+		t.Errorf("TestUnorderedParallelMap: sorted out = %v, want %v", out, want)
+	}
+
+	if most < 2 || most > 4 {
+		t.Errorf("TestUnorderedParallelMap: %v calls ran at once, want 2 to 4", most)
+	}
+
+	checkGoroutines(t, "TestUnorderedParallelMap", before)
+}
+
+func TestParallelMapBounded(t *testing.T) {
+	for _, ordered := range []bool{true, false} {
+		var pulled int32
+
+		src := Map(func(x int) int {
+			atomic.AddInt32(&pulled, 1)
+			return x
+		}, Range(0, 1000))
+
+		iter := parallelMap(4, func(x int) int { return x }, src, ordered)
+
+		// take one value, then give the workers time to run ahead of us if they would.
+		<-iter.Each
+		time.Sleep(10 * time.Millisecond)
+
+		if n := atomic.LoadInt32(&pulled); n > 16 {
+			t.Errorf("TestParallelMapBounded: ordered = %v: %v values pulled for 1 consumed",
+				ordered, n)
+		}
+
+		iter.Close()
+	}
+}
+
+func TestParallelMapClose(t *testing.T) {
+	for _, ordered := range []bool{true, false} {
+		before := runtime.NumGoroutine()
+
+		src, exited := naturals(context.Background())
+		iter := parallelMap(3, func(x int) int { return x }, src, ordered)
+
+		<-iter.Each
+		iter.Close()
+
+		select {
+		case <-exited:
+		default:
+			t.Errorf("TestParallelMapClose: ordered = %v: upstream still producing after Close",
+				ordered)
+		}
+
+		checkGoroutines(t, "TestParallelMapClose", before)
+	}
+}
+
+func TestParallelMapErr(t *testing.T) {
+	boom := errors.New("boom")
+
+	for _, ordered := range []bool{true, false} {
+		iter := Concat(Range(0, 10), Fail[int](boom))
+
+		out, err := ToSliceErr(parallelMap(3, func(x int) int { return x }, iter, ordered))
+
+		if err != boom || len(out) != 10 {
+			t.Errorf("TestParallelMapErr: ordered = %v: out = %v, err = %v, want 10 values, %v",
+				ordered, out, err, boom)
+		}
+	}
+}
+
+func TestParallelMapPanic(t *testing.T) {
+	for _, ordered := range []bool{true, false} {
+		before := runtime.NumGoroutine()
+
+		v := recovered(func() { _ = ToSlice(parallelMap(3, boomAt(5), Range(0, 100), ordered)) })
+
+		if !isBoom(v) {
+			t.Errorf("TestParallelMapPanic: ordered = %v: panicked with %v, want PanicError of boom",
+				ordered, v)
+		}
+
+		checkGoroutines(t, "TestParallelMapPanic", before)
+	}
+}
@@ -0,0 +1,126 @@
+package giter
+
+// A Peekable consumes an Iterator one value at a time, allowing the next value to be looked at
+// without consuming it, and consumed values to be pushed back.
+//
+// Values are pulled in the goroutine calling Next, Peek or NextIf. A Peekable is not safe for use
+// by multiple goroutines at once.
+//
+// A Peekable must be closed, unless it's handed over to the rest of the package via Iterator.
+type Peekable[T any] struct {
+	up puller[T]
+
+	// pushed holds the values pushed back, the next of them last.
+	pushed []T
+
+	// pulled counts the values pulled from up, to keep track of the size hint.
+	size   SizeHint
+	pulled int
+
+	// exhausted is set once up has returned false.
+	exhausted bool
+}
+
+// Peeking returns a Peekable consuming the given Iterator.
+func Peeking[T any](iter Iterator[T]) *Peekable[T] {
+	return &Peekable[T]{
+		up:   pull(iter),
+		size: iter.size,
+	}
+}
+
+// Next consumes and returns the next value, or false if there are no more.
+func (p *Peekable[T]) Next() (T, bool) {
+	return p.next(nil)
+}
+
+// next implements Next, giving up if stop is signaled while waiting on the consumed Iterator.
+func (p *Peekable[T]) next(stop <-chan interface{}) (x T, ok bool) {
+	if n := len(p.pushed); n > 0 {
+		var zero T
+
+		x = p.pushed[n-1]
+		p.pushed[n-1] = zero
+		p.pushed = p.pushed[:n-1]
+
+		return x, true
+	} else if p.exhausted {
+		return x, false
+	}
+
+	x, ok = p.up.next(stop)
+	if !ok {
+		p.exhausted = !stopped(stop)
+		return x, false
+	}
+
+	p.pulled++
+
+	return x, true
+}
+
+// Peek returns the next value without consuming it, or false if there are no more.
+func (p *Peekable[T]) Peek() (x T, ok bool) {
+	x, ok = p.Next()
+	if ok {
+		p.PushBack(x)
+	}
+
+	return x, ok
+}
+
+// NextIf consumes and returns the next value if it matches the given predicate. Otherwise, or if
+// there are no more values, it returns false and consumes nothing.
+func (p *Peekable[T]) NextIf(pred func(T) bool) (x T, ok bool) {
+	x, ok = p.Next()
+	if !ok {
+		return x, false
+	} else if !pred(x) {
+		p.PushBack(x)
+
+		var zero T
+
+		return zero, false
+	}
+
+	return x, true
+}
+
+// PushBack makes the given value the next one to be returned, ahead of any others. It needn't be
+// a value that was consumed before, and may be called any number of times.
+func (p *Peekable[T]) PushBack(x T) {
+	p.pushed = append(p.pushed, x)
+}
+
+// Err returns the error that ended the consumed Iterator, if any. It may only be called once Next
+// has returned false.
+func (p *Peekable[T]) Err() error {
+	return p.up.Err()
+}
+
+// Close releases the consumed Iterator, and any values pushed back.
+func (p *Peekable[T]) Close() {
+	clear(&p.pushed)
+	p.up.Close()
+}
+
+// Iterator returns an Iterator emitting the values the Peekable has yet to return, starting with
+// any pushed back, so that it can be passed to the rest of this package.
+//
+// The Peekable must not be used afterwards; closing the Iterator closes it.
+func (p *Peekable[T]) Iterator() Iterator[T] {
+	size := p.size
+	if size.Known() {
+		size.N += len(p.pushed) - p.pulled
+	}
+
+	if p.exhausted {
+		size = ExactSize(len(p.pushed))
+	}
+
+	return fromPuller(size, puller[T]{
+		next:  p.next,
+		err:   p.Err,
+		close: p.Close,
+	})
+}
@@ -0,0 +1,112 @@
+package giter
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"unicode"
+)
+
+func TestPeekable(t *testing.T) {
+	p := Peeking(Slice([]int{1, 2, 3}))
+	defer p.Close()
+
+	if x, ok := p.Peek(); !ok || x != 1 {
+		t.Errorf("TestPeekable: Peek() = %v, %v, want 1, true", x, ok)
+	}
+
+	if x, ok := p.Next(); !ok || x != 1 {
+		t.Errorf("TestPeekable: Next() = %v, %v, want 1, true", x, ok)
+	}
+
+	if x, ok := p.NextIf(func(x int) bool { return x > 2 }); ok {
+		t.Errorf("TestPeekable: NextIf(> 2) = %v, %v, want false", x, ok)
+	}
+
+	if x, ok := p.NextIf(func(x int) bool { return x == 2 }); !ok || x != 2 {
+		t.Errorf("TestPeekable: NextIf(== 2) = %v, %v, want 2, true", x, ok)
+	}
+
+	p.PushBack(2)
+	p.PushBack(1)
+
+	want := []int{1, 2, 3}
+	out := []int{}
+
+	for x, ok := p.Next(); ok; x, ok = p.Next() {
+		out = append(out, x)
+	}
+
+	if !reflect.DeepEqual(out, want) {
+		t.Errorf("TestPeekable: after PushBack(2), PushBack(1): %v, want %v", out, want)
+	}
+
+	if x, ok := p.Peek(); ok {
+		t.Errorf("TestPeekable: exhausted Peek() = %v, %v, want false", x, ok)
+	}
+
+	if err := p.Err(); err != nil {
+		t.Errorf("TestPeekable: Err() = %v, want nil", err)
+	}
+}
+
+func TestPeekableTokenize(t *testing.T) {
+	p := Peeking(Slice([]rune("ab 12 c3")))
+	defer p.Close()
+
+	// group consecutive letters and digits.
+	var tokens []string
+
+	for r, ok := p.Next(); ok; r, ok = p.Next() {
+		if unicode.IsSpace(r) {
+			continue
+		}
+
+		token := []rune{r}
+		same := func(next rune) bool {
+			return !unicode.IsSpace(next) && unicode.IsDigit(next) == unicode.IsDigit(r)
+		}
+
+		for next, ok := p.NextIf(same); ok; next, ok = p.NextIf(same) {
+			token = append(token, next)
+		}
+
+		tokens = append(tokens, string(token))
+	}
+
+	if want := []string{"ab", "12", "c", "3"}; !reflect.DeepEqual(tokens, want) {
+		t.Errorf("TestPeekableTokenize: tokens = %v, want %v", tokens, want)
+	}
+}
+
+func TestPeekableIterator(t *testing.T) {
+	p := Peeking(Range(0, 5))
+
+	p.Next()
+	p.Peek()
+
+	iter := p.Iterator()
+
+	if out := iter.Size(); out != ExactSize(4) {
+		t.Errorf("TestPeekableIterator: Size() = %v, want %v", out, ExactSize(4))
+	}
+
+	if out := ToSlice(Map(func(x int) int { return 2 * x }, iter)); !reflect.DeepEqual(out,
+		[]int{2, 4, 6, 8}) {
+		t.Errorf("TestPeekableIterator: out = %v, want [2 4 6 8]", out)
+	}
+}
+
+func TestPeekableErr(t *testing.T) {
+	boom := errors.New("boom")
+
+	p := Peeking(Concat(One(1), Fail[int](boom)))
+	defer p.Close()
+
+	for _, ok := p.Next(); ok; _, ok = p.Next() {
+	}
+
+	if err := p.Err(); err != boom {
+		t.Errorf("TestPeekableErr: Err() = %v, want %v", err, boom)
+	}
+}
@@ -0,0 +1,242 @@
+package giter
+
+import "sync"
+
+// puller produces values on demand, in whichever goroutine calls next.
+//
+// Pullers are how most of this package's iterators are implemented: chaining e.g. Map onto Filter
+// onto Slice chains their pullers, so that pulling a value from the Map calls through to the Slice
+// without any goroutine switches. Iterators created from pullers still start a pump goroutine each,
+// so that Each works, but it exits once the Iterator is chained onto; only goroutine-backed
+// iterators (those created via Make, Merge and the like) keep handing values over a channel.
+//
+// A puller is owned by one goroutine at a time, which need not be the one that created it.
+type puller[T any] struct {
+	// next returns the next value, or false once no more values will be produced: either because
+	// the source is exhausted or has failed, or because stop was signaled while next was blocked
+	// waiting on a goroutine-backed iterator. It must not be called again after returning false.
+	//
+	// Only pullers that block (i.e. those receiving from goroutine-backed iterators) need watch
+	// stop themselves; others just pass it along to their upstream pullers.
+	next func(stop <-chan interface{}) (T, bool)
+
+	// err returns the error that ended the puller, if any. It may only be called once next has
+	// returned false for a reason other than stop being signaled. If nil, the puller can't fail.
+	err func() error
+
+	// close releases the puller along with any upstream pullers and iterators. It may be called at
+	// any time, more than once. If nil, there's nothing to release.
+	close func()
+}
+
+func (pl *puller[T]) Err() error {
+	if pl.err == nil {
+		return nil
+	}
+
+	return pl.err()
+}
+
+func (pl *puller[T]) Close() {
+	if pl.close != nil {
+		pl.close()
+	}
+}
+
+// pump hands over the puller of an Iterator created via fromPuller.
+//
+// The pump goroutine is started along with the Iterator, as there's no telling when Each will be
+// received from. Until the Iterator is claimed by a pull-based consumer, the goroutine pumps values
+// from the puller to Each, so that the Iterator can be consumed like any other. Once claimed, the
+// goroutine hands over the value it was trying to emit (if any) and the puller, and exits.
+//
+// Stages claim the iterators they consume as soon as they're created, so that when a chain of them
+// is built, the pumps of all but the last usually exit without having computed anything.
+type pump[T any] struct {
+	pl puller[T]
+
+	claimed   chan interface{}
+	claimOnce sync.Once
+
+	// written by the pump goroutine before it exits.
+	pending    T
+	hasPending bool
+	exhausted  bool
+}
+
+// stopped returns true if stop has been signaled.
+func stopped(stop <-chan interface{}) bool {
+	select {
+	case <-stop:
+		return true
+	default:
+		return false
+	}
+}
+
+// fromPuller creates an Iterator emitting the values produced by a given puller, with a given size
+// hint.
+func fromPuller[T any](size SizeHint, pl puller[T]) Iterator[T] {
+	values := make(chan T)
+
+	p := &producer{
+		stopChan: make(chan interface{}),
+		done:     make(chan interface{}),
+	}
+
+	pu := &pump[T]{
+		pl:      pl,
+		claimed: make(chan interface{}),
+	}
+
+	go func() {
+		defer close(p.done)
+		defer close(values)
+		defer func() {
+			if p.capture(recover()) {
+				pl.Close()
+			}
+		}()
+
+		for {
+			// once claimed, the puller's values are the consumer's to compute.
+			select {
+			case <-pu.claimed:
+				return
+			default:
+			}
+
+			x, ok := pl.next(p.stopChan)
+			if !ok {
+				if !stopped(p.stopChan) {
+					pu.exhausted = true
+					p.err = pl.Err()
+				}
+
+				pl.Close()
+				return
+			}
+
+			select {
+			case values <- x:
+			case <-pu.claimed:
+				pu.pending, pu.hasPending = x, true
+				return
+			case <-p.stopChan:
+				pl.Close()
+				return
+			}
+		}
+	}()
+
+	return Iterator[T]{
+		Each: values,
+		p:    p,
+		pump: pu,
+		size: size,
+	}
+}
+
+// pull takes over production of an Iterator's values, returning a puller that produces them in the
+// calling goroutine.
+//
+// The Iterator must not be used otherwise afterwards; the puller must be closed instead.
+//
+// Iterators created from pullers are claimed at once, so that their pump goroutine stops before
+// computing any further value; the first call to next waits for it to hand over. Other iterators
+// are pulled by receiving from Each.
+func pull[T any](iter Iterator[T]) puller[T] {
+	if iter.pump == nil {
+		return puller[T]{
+			next: func(stop <-chan interface{}) (T, bool) {
+				x, ok, stopped := recv(stop, iter)
+				if !ok && !stopped {
+					iter.p.rethrow()
+				}
+
+				return x, ok
+			},
+			err:   iter.Err,
+			close: iter.Close,
+		}
+	}
+
+	pu := iter.pump
+	pu.claimOnce.Do(func() { close(pu.claimed) })
+
+	taken := false
+
+	return puller[T]{
+		next: func(stop <-chan interface{}) (x T, ok bool) {
+			if !taken {
+				select {
+				case <-iter.p.done:
+				case <-stop:
+					return x, false
+				}
+
+				taken = true
+
+				iter.p.rethrow()
+
+				if pu.hasPending {
+					var zero T
+
+					x = pu.pending
+					pu.pending, pu.hasPending = zero, false
+
+					return x, true
+				}
+			}
+
+			if pu.exhausted {
+				return x, false
+			}
+
+			return pullNext(pu.pl, stop)
+		},
+		err: func() error {
+			if pu.exhausted {
+				return iter.p.err
+			}
+
+			return pu.pl.Err()
+		},
+		close: func() {
+			// stop the pump if it's still running, then release the puller whether the pump
+			// did or not.
+			iter.Close()
+			pu.pl.Close()
+		},
+	}
+}
+
+// pullNext calls a puller's next, panicking with a PanicError if it panics, just as it would had
+// the puller run in its pump goroutine: consumers see the same whichever goroutine produced.
+func pullNext[T any](pl puller[T], stop <-chan interface{}) (T, bool) {
+	defer func() {
+		if v := recover(); v != nil {
+			panic(newPanicError(v))
+		}
+	}()
+
+	return pl.next(stop)
+}
+
+// each pulls the values of an Iterator in the calling goroutine, passing them to f until f returns
+// false or the Iterator is exhausted. It then closes the Iterator and returns its error, if any.
+func each[T any](iter Iterator[T], f func(T) bool) error {
+	pl := pull(iter)
+	defer pl.Close()
+
+	for {
+		x, ok := pl.next(nil)
+		if !ok {
+			return pl.Err()
+		}
+
+		if !f(x) {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package giter
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPullAfterEach(t *testing.T) {
+	xs := []int{1, 2, 3, 4, 5}
+
+	iter := Map(func(x int) int { return 2 * x }, Slice(xs))
+
+	first := <-iter.Each
+
+	// the rest, pulled directly, must pick up where Each left off.
+	rest := ToSlice(iter)
+
+	out := append([]int{first}, rest...)
+	want := []int{2, 4, 6, 8, 10}
+
+	if !reflect.DeepEqual(want, out) {
+		t.Errorf("TestPullAfterEach: out = %v, want %v", out, want)
+	}
+}
+
+func TestPullFromGoroutine(t *testing.T) {
+	release := make(chan interface{})
+
+	// a goroutine-backed source that emits nothing until we say so.
+	src := Make(
+		func(values chan<- int, stopChan <-chan interface{}) {
+			select {
+			case <-release:
+			case <-stopChan:
+				return
+			}
+
+			for _, x := range []int{1, 2, 3} {
+				select {
+				case values <- x:
+				case <-stopChan:
+					return
+				}
+			}
+		})
+
+	// building a pipeline mustn't wait on its source.
+	iter := Filter(func(x int) bool { return x != 2 }, Map(func(x int) int { return x }, src))
+
+	close(release)
+
+	out := ToSlice(iter)
+	want := []int{1, 3}
+
+	if !reflect.DeepEqual(want, out) {
+		t.Errorf("TestPullFromGoroutine: out = %v, want %v", out, want)
+	}
+}
+
+func TestPullExhausted(t *testing.T) {
+	iter := Slice([]int{})
+
+	// let the pump notice it's exhausted before anybody claims it.
+	iter.Wait()
+
+	if out := ToSlice(Map(func(x int) int { return x }, iter)); len(out) != 0 {
+		t.Errorf("TestPullExhausted: out = %v, want []", out)
+	}
+}
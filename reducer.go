@@ -0,0 +1,229 @@
+package giter
+
+import "sync"
+
+// A Reducer is a Collector broken into parts, so that it can be composed with others, or run over
+// parts of an Iterator's values in parallel.
+//
+// A reduction starts with an accumulator of type A from Init, adds each value to it with Add, and
+// turns it into the result with Finish. Merge, if set, combines the accumulators of two reductions
+// over different values into one, as if all values had been added to the first; Reducers without
+// Merge can't be run in parallel.
+//
+// Add and Merge may modify the accumulators they're given, and return them or new ones.
+type Reducer[T, A, R any] struct {
+	Init   func() A
+	Add    func(acc A, x T) A
+	Finish func(acc A) R
+	Merge  func(acc, other A) A
+}
+
+// Collector returns a Collector that performs the reduction, for use with Collect and friends.
+func (r Reducer[T, A, R]) Collector() Collector[T, R] {
+	return func(each <-chan T) R {
+		acc := r.Init()
+
+		for x := range each {
+			acc = r.Add(acc, x)
+		}
+		return r.Finish(acc)
+	}
+}
+
+// Reduce consumes an Iterator and returns the result of reducing its values via a Reducer.
+func Reduce[T, A, R any](r Reducer[T, A, R], iter Iterator[T]) R {
+	out, _ := ReduceErr(r, iter)
+	return out
+}
+
+// ReduceErr is as Reduce, but additionally returns the error that ended the Iterator, if any.
+//
+// When an error is returned, the result is the reduction of the values emitted before the Iterator
+// failed.
+func ReduceErr[T, A, R any](r Reducer[T, A, R], iter Iterator[T]) (R, error) {
+	acc := r.Init()
+
+	err := each(iter, func(x T) bool {
+		acc = r.Add(acc, x)
+		return true
+	})
+
+	return r.Finish(acc), err
+}
+
+// ReduceParallel is as Reduce, but reduces the Iterator's values on up to the given number of
+// goroutines at once, each adding the values it receives to an accumulator of its own, then merges
+// their accumulators via the Reducer's Merge.
+//
+// The order in which values are added and accumulators are merged is undefined, so this is only
+// suitable for reductions whose result doesn't depend on it (e.g. sums or counts). Reducers without
+// Merge are reduced by a single goroutine instead, as by Reduce.
+func ReduceParallel[T, A, R any](workers int, r Reducer[T, A, R], iter Iterator[T]) R {
+	out, _ := ReduceParallelErr(workers, r, iter)
+	return out
+}
+
+// ReduceParallelErr is as ReduceParallel, but additionally returns the error that ended the
+// Iterator, if any.
+func ReduceParallelErr[T, A, R any](
+	workers int,
+	r Reducer[T, A, R],
+	iter Iterator[T],
+) (R, error) {
+	if r.Merge == nil || workers <= 1 {
+		return ReduceErr(r, iter)
+	}
+
+	defer iter.Close()
+
+	accs := make([]A, workers)
+	panics := make([]*PanicError, workers)
+
+	// quit stops the other workers once one panics.
+	quit := make(chan interface{})
+	var quitOnce sync.Once
+
+	var wg sync.WaitGroup
+
+	for i := range accs {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			// we're not the consumer's goroutine, so have to pass on any panic to it.
+			defer func() {
+				if v := recover(); v != nil {
+					panics[i] = newPanicError(v)
+					quitOnce.Do(func() { close(quit) })
+				}
+			}()
+
+			accs[i] = r.Init()
+
+			for {
+				select {
+				case x, ok := <-iter.Each:
+					if !ok {
+						return
+					}
+
+					accs[i] = r.Add(accs[i], x)
+				case <-quit:
+					return
+				}
+			}
+		}(i)
+	}
+
+	wg.Wait()
+
+	for _, pe := range panics {
+		if pe != nil {
+			panic(pe)
+		}
+	}
+
+	acc := accs[0]
+
+	for _, other := range accs[1:] {
+		acc = r.Merge(acc, other)
+	}
+
+	return r.Finish(acc), iter.Err()
+}
+
+// SliceReducer returns a Reducer that creates a slice from an Iterator's values, as SliceCollector
+// does.
+func SliceReducer[T any]() Reducer[T, []T, []T] {
+	return Reducer[T, []T, []T]{
+		Init:   func() []T { return nil },
+		Add:    func(xs []T, x T) []T { return append(xs, x) },
+		Finish: func(xs []T) []T { return xs },
+		Merge:  func(xs, ys []T) []T { return append(xs, ys...) },
+	}
+}
+
+// MapReducer returns a Reducer that creates a map from an Iterator of KVPairs, as MapCollector
+// does. Merging takes the values of the accumulator being merged in for keys present in both.
+func MapReducer[K comparable, V any]() Reducer[KVPair[K, V], map[K]V, map[K]V] {
+	return Reducer[KVPair[K, V], map[K]V, map[K]V]{
+		Init: func() map[K]V { return map[K]V{} },
+		Add: func(m map[K]V, x KVPair[K, V]) map[K]V {
+			m[x.Key] = x.Value
+			return m
+		},
+		Finish: func(m map[K]V) map[K]V { return m },
+		Merge: func(m, other map[K]V) map[K]V {
+			for k, v := range other {
+				m[k] = v
+			}
+			return m
+		},
+	}
+}
+
+// Mapping returns a Reducer that transforms each value via a given function before adding it via
+// a given Reducer.
+func Mapping[T, U, A, R any](f func(T) U, r Reducer[U, A, R]) Reducer[T, A, R] {
+	return Reducer[T, A, R]{
+		Init:   r.Init,
+		Add:    func(acc A, x T) A { return r.Add(acc, f(x)) },
+		Finish: r.Finish,
+		Merge:  r.Merge,
+	}
+}
+
+// Filtering returns a Reducer that adds only the values matching a given predicate via a given
+// Reducer.
+func Filtering[T, A, R any](pred func(T) bool, r Reducer[T, A, R]) Reducer[T, A, R] {
+	return Reducer[T, A, R]{
+		Init: r.Init,
+		Add: func(acc A, x T) A {
+			if !pred(x) {
+				return acc
+			}
+
+			return r.Add(acc, x)
+		},
+		Finish: r.Finish,
+		Merge:  r.Merge,
+	}
+}
+
+// Teeing returns a Reducer that adds each value via two given Reducers, and combines their results
+// via a given function.
+//
+// The returned Reducer can be merged if both given ones can.
+func Teeing[T, A1, R1, A2, R2, R any](
+	r1 Reducer[T, A1, R1],
+	r2 Reducer[T, A2, R2],
+	combine func(R1, R2) R,
+) Reducer[T, Pair[A1, A2], R] {
+	tee := Reducer[T, Pair[A1, A2], R]{
+		Init: func() Pair[A1, A2] { return Pair[A1, A2]{r1.Init(), r2.Init()} },
+		Add: func(acc Pair[A1, A2], x T) Pair[A1, A2] {
+			return Pair[A1, A2]{r1.Add(acc.First, x), r2.Add(acc.Second, x)}
+		},
+		Finish: func(acc Pair[A1, A2]) R {
+			return combine(r1.Finish(acc.First), r2.Finish(acc.Second))
+		},
+	}
+
+	if r1.Merge != nil && r2.Merge != nil {
+		tee.Merge = func(acc, other Pair[A1, A2]) Pair[A1, A2] {
+			return Pair[A1, A2]{r1.Merge(acc.First, other.First), r2.Merge(acc.Second, other.Second)}
+		}
+	}
+
+	return tee
+}
+
+// Paired returns a Reducer that adds each value via two given Reducers, resulting in the Pair of
+// their results.
+func Paired[T, A1, R1, A2, R2 any](
+	r1 Reducer[T, A1, R1],
+	r2 Reducer[T, A2, R2],
+) Reducer[T, Pair[A1, A2], Pair[R1, R2]] {
+	return Teeing(r1, r2, func(x R1, y R2) Pair[R1, R2] { return Pair[R1, R2]{x, y} })
+}
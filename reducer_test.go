@@ -0,0 +1,125 @@
+package giter
+
+import (
+	"errors"
+	"reflect"
+	"runtime"
+	"sort"
+	"testing"
+)
+
+// summing returns a Reducer summing ints.
+func summing() Reducer[int, int, int] {
+	return Reducer[int, int, int]{
+		Init:   func() int { return 0 },
+		Add:    func(acc, x int) int { return acc + x },
+		Finish: func(acc int) int { return acc },
+		Merge:  func(acc, other int) int { return acc + other },
+	}
+}
+
+func TestReduce(t *testing.T) {
+	xs := []int{1, 2, 3, 4, 5}
+
+	if out := Reduce(SliceReducer[int](), Slice(xs)); !reflect.DeepEqual(out, xs) {
+		t.Errorf("TestReduce: Reduce(SliceReducer, %v) = %v", xs, out)
+	}
+
+	if out := Collect(SliceReducer[int]().Collector(), Slice(xs)); !reflect.DeepEqual(out, xs) {
+		t.Errorf("TestReduce: Collect(SliceReducer.Collector(), %v) = %v", xs, out)
+	}
+
+	m, _, _, _ := testMap()
+
+	if out := Reduce(MapReducer[string, int](), MapPairs(m)); !reflect.DeepEqual(out, m) {
+		t.Errorf("TestReduce: Reduce(MapReducer, %v) = %v", m, out)
+	}
+
+	boom := errors.New("boom")
+
+	if out, err := ReduceErr(summing(), Concat(Slice(xs), Fail[int](boom))); out != 15 || err != boom {
+		t.Errorf("TestReduce: ReduceErr(summing, failing) = %v, %v, want 15, %v", out, err, boom)
+	}
+}
+
+func TestReducerCombinators(t *testing.T) {
+	xs := []int{1, 2, 3, 4, 5}
+	even := func(x int) bool { return x%2 == 0 }
+	double := func(x int) int { return 2 * x }
+
+	if out := Reduce(Mapping(double, summing()), Slice(xs)); out != 30 {
+		t.Errorf("TestReducerCombinators: Mapping(double, summing) = %v, want 30", out)
+	}
+
+	if out := Reduce(Filtering(even, summing()), Slice(xs)); out != 6 {
+		t.Errorf("TestReducerCombinators: Filtering(even, summing) = %v, want 6", out)
+	}
+
+	counting := Mapping(func(int) int { return 1 }, summing())
+	mean := Teeing(summing(), counting, func(sum, n int) float64 { return float64(sum) / float64(n) })
+
+	if out := Reduce(mean, Slice(xs)); out != 3 {
+		t.Errorf("TestReducerCombinators: Teeing(summing, counting, /) = %v, want 3", out)
+	}
+
+	want := Pair[int, []int]{6, []int{2, 4}}
+
+	out := Reduce(Paired(Filtering(even, summing()), Filtering(even, SliceReducer[int]())),
+		Slice(xs))
+
+	if !reflect.DeepEqual(out, want) {
+		t.Errorf("TestReducerCombinators: Paired(...) = %v, want %v", out, want)
+	}
+}
+
+func TestReduceParallel(t *testing.T) {
+	before := runtime.NumGoroutine()
+
+	// the sum, count and values must all be in once merged, whatever worker they went to.
+	r := Paired(Paired(summing(), Mapping(func(int) int { return 1 }, summing())),
+		SliceReducer[int]())
+
+	out := ReduceParallel(4, r, Range(0, 1000))
+
+	if out.First.First != 999*1000/2 || out.First.Second != 1000 {
+		t.Errorf("TestReduceParallel: sum, count = %v, want %v, 1000", out.First, 999*1000/2)
+	}
+
+	sort.Ints(out.Second)
+
+	if want := ToSlice(Range(0, 1000)); !reflect.DeepEqual(out.Second, want) {
+		t.Errorf("TestReduceParallel: values = %v, want %v", out.Second, want)
+	}
+
+	checkGoroutines(t, "TestReduceParallel", before)
+
+	// can't be merged, so must be reduced in order.
+	unmergeable := SliceReducer[int]()
+	unmergeable.Merge = nil
+
+	if out := ReduceParallel(4, unmergeable, Range(0, 100)); !reflect.DeepEqual(out,
+		ToSlice(Range(0, 100))) {
+		t.Errorf("TestReduceParallel: unmergeable = %v, want [0, 100)", out)
+	}
+}
+
+func TestReduceParallelErr(t *testing.T) {
+	boom := errors.New("boom")
+
+	out, err := ReduceParallelErr(4, summing(), Concat(Range(0, 100), Fail[int](boom)))
+
+	if out != 99*100/2 || err != boom {
+		t.Errorf("TestReduceParallelErr: out = %v, err = %v, want %v, %v", out, err, 99*100/2,
+			boom)
+	}
+
+	before := runtime.NumGoroutine()
+
+	r := Mapping(boomAt(5), summing())
+
+	if v := recovered(func() { ReduceParallel(4, r, Range(0, 100)) }); !isBoom(v) {
+		t.Errorf("TestReduceParallelErr: panicked with %v, want PanicError of boom", v)
+	}
+
+	checkGoroutines(t, "TestReduceParallelErr", before)
+}
@@ -0,0 +1,60 @@
+//go:build go1.23
+
+package giter
+
+import "iter"
+
+// FromSeq returns an Iterator emitting the values of a given iter.Seq.
+//
+// The sequence is pulled one value at a time as the Iterator's values are consumed, and is stopped
+// when the Iterator is closed.
+func FromSeq[T any](seq iter.Seq[T]) Iterator[T] {
+	next, stop := iter.Pull(seq)
+
+	return fromPuller(SizeHint{}, puller[T]{
+		next: func(_ <-chan interface{}) (T, bool) {
+			return next()
+		},
+		close: stop,
+	})
+}
+
+// FromSeq2 returns an Iterator emitting the key-value pairs of a given iter.Seq2 as KVPairs.
+//
+// Same caveats apply as in FromSeq.
+func FromSeq2[K comparable, V any](seq iter.Seq2[K, V]) Iterator[KVPair[K, V]] {
+	next, stop := iter.Pull2(seq)
+
+	return fromPuller(SizeHint{}, puller[KVPair[K, V]]{
+		next: func(_ <-chan interface{}) (KVPair[K, V], bool) {
+			k, v, ok := next()
+			return KVPair[K, V]{k, v}, ok
+		},
+		close: stop,
+	})
+}
+
+// Seq returns an iter.Seq emitting the values of the Iterator, for use with range loops and
+// packages built around iter.Seq.
+//
+// The Iterator is consumed by ranging over the sequence, and closed once the range loop ends,
+// including when it ends early (e.g. via break). The sequence may thus only be ranged over once.
+//
+// If the Iterator fails, the sequence simply ends; consume the Iterator some other way (e.g. via
+// ToSliceErr) if its error matters.
+func (i Iterator[T]) Seq() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		_ = each(i, yield)
+	}
+}
+
+// Seq2 returns an iter.Seq2 emitting the key-value pairs of an Iterator of KVPairs.
+//
+// Same caveats apply as in Iterator.Seq.
+func Seq2[K comparable, V any](i Iterator[KVPair[K, V]]) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		_ = each(i, func(x KVPair[K, V]) bool {
+			return yield(x.Key, x.Value)
+		})
+	}
+}
@@ -0,0 +1,97 @@
+//go:build go1.23
+
+package giter
+
+import (
+	"maps"
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+func TestFromSeq(t *testing.T) {
+	xs := []int{1, 2, 3, 4, 5}
+	want := []int{2, 4}
+
+	seq := func(yield func(int) bool) {
+		for _, x := range xs {
+			if !yield(x) {
+				return
+			}
+		}
+	}
+
+	out := ToSlice(Filter(func(x int) bool { return x%2 == 0 }, FromSeq(seq)))
+
+	if !reflect.DeepEqual(want, out) {
+		t.Errorf("TestFromSeq: out = %v, want %v", out, want)
+	}
+}
+
+func TestFromSeqClose(t *testing.T) {
+	finished := false
+
+	seq := func(yield func(int) bool) {
+		defer func() { finished = true }()
+
+		for i := 0; ; i++ {
+			if !yield(i) {
+				return
+			}
+		}
+	}
+
+	if x := First(FromSeq(seq)); x == nil || *x != 0 {
+		t.Errorf("TestFromSeqClose: First(naturals) = %v, want 0", x)
+	}
+
+	if !finished {
+		t.Errorf("TestFromSeqClose: sequence not stopped on Close")
+	}
+}
+
+func TestFromSeq2(t *testing.T) {
+	want, _, _, _ := testMap()
+
+	out := ToMap(FromSeq2(maps.All(want)))
+
+	if !reflect.DeepEqual(want, out) {
+		t.Errorf("TestFromSeq2: out = %v, want %v", out, want)
+	}
+}
+
+func TestSeq(t *testing.T) {
+	want := []int{2, 4, 6}
+
+	out := []int{}
+
+	for x := range Map(func(x int) int { return 2 * x }, Slice([]int{1, 2, 3})).Seq() {
+		out = append(out, x)
+	}
+
+	if !reflect.DeepEqual(want, out) {
+		t.Errorf("TestSeq: out = %v, want %v", out, want)
+	}
+}
+
+func TestSeqBreak(t *testing.T) {
+	before := runtime.NumGoroutine()
+
+	iter := Merge(Range(0, 100), Range(100, 200))
+
+	for range iter.Seq() {
+		break
+	}
+
+	checkGoroutines(t, "TestSeqBreak", before)
+}
+
+func TestSeq2(t *testing.T) {
+	want, _, _, pairs := testMap()
+
+	out := maps.Collect(Seq2(Slice(pairs)))
+
+	if !reflect.DeepEqual(want, out) {
+		t.Errorf("TestSeq2: out = %v, want %v", out, want)
+	}
+}
@@ -0,0 +1,240 @@
+package giter
+
+// SizeKind tells how much a SizeHint knows of the number of values an Iterator emits.
+type SizeKind int
+
+const (
+	// SizeUnknown indicates nothing is known of the number of values emitted.
+	SizeUnknown SizeKind = iota
+
+	// SizeExact indicates exactly SizeHint.N values are emitted.
+	SizeExact
+
+	// SizeAtMost indicates at most SizeHint.N values are emitted.
+	SizeAtMost
+)
+
+// A SizeHint tells how many values an Iterator emits, as far as is known when it's created.
+//
+// Hints assume that the Iterator runs to completion: one that fails or is closed early emits fewer
+// values. Values consumed from Each aren't accounted for either.
+type SizeHint struct {
+	Kind SizeKind
+	N    int
+}
+
+// ExactSize returns a SizeHint of exactly n values.
+func ExactSize(n int) SizeHint {
+	return SizeHint{SizeExact, n}
+}
+
+// AtMostSize returns a SizeHint of at most n values.
+func AtMostSize(n int) SizeHint {
+	return SizeHint{SizeAtMost, n}
+}
+
+// Known returns true if the hint tells anything of the number of values emitted.
+func (h SizeHint) Known() bool {
+	return h.Kind != SizeUnknown
+}
+
+// AtMost returns a hint of at most as many values as h, as for an Iterator that emits some subset
+// of the values of an Iterator with hint h.
+func (h SizeHint) AtMost() SizeHint {
+	if h.Kind == SizeExact {
+		h.Kind = SizeAtMost
+	}
+
+	return h
+}
+
+// Plus returns a hint of as many values as both h and o, as for an Iterator that emits the values
+// of an Iterator with hint h and then those of an Iterator with hint o.
+func (h SizeHint) Plus(o SizeHint) SizeHint {
+	if !h.Known() || !o.Known() {
+		return SizeHint{}
+	}
+
+	if h.Kind == SizeExact && o.Kind == SizeExact {
+		return ExactSize(h.N + o.N)
+	}
+
+	return AtMostSize(h.N + o.N)
+}
+
+// maxCapacity is the most values preallocated for an Iterator, however many its hint claims.
+const maxCapacity = 1 << 16
+
+// capacity returns how many values should be preallocated for an Iterator with hint h.
+//
+// Only exact hints are trusted: bounds may be much larger than what's actually emitted. Even exact
+// hints are capped at maxCapacity, as an Iterator that fails or is closed early emits fewer values
+// than its hint, and collecting more grows the allocation as usual.
+func (h SizeHint) capacity() int {
+	if h.Kind != SizeExact || h.N < 0 {
+		return 0
+	} else if h.N > maxCapacity {
+		return maxCapacity
+	}
+
+	return h.N
+}
+
+// Size returns a hint of how many values the Iterator emits.
+func (i Iterator[T]) Size() SizeHint {
+	return i.size
+}
+
+// sized returns the Iterator with the given size hint.
+func (i Iterator[T]) sized(h SizeHint) Iterator[T] {
+	i.size = h
+	return i
+}
+
+// sumSizes returns a hint of as many values as all of the given iterators together.
+func sumSizes[T any](iters []Iterator[T]) SizeHint {
+	h := ExactSize(0)
+
+	for _, iter := range iters {
+		h = h.Plus(iter.size)
+	}
+
+	return h
+}
+
+// chunkedSize returns a hint of how many chunks of n values are made of the values of an Iterator
+// with hint h.
+func chunkedSize(n int, h SizeHint) SizeHint {
+	if !h.Known() || n <= 0 {
+		return SizeHint{}
+	}
+
+	h.N = (h.N + n - 1) / n
+
+	return h
+}
+
+// takenSize returns a hint of how many values are emitted by taking the first n values of an
+// Iterator with hint h.
+func takenSize(n int, h SizeHint) SizeHint {
+	if n <= 0 {
+		return ExactSize(0)
+	} else if !h.Known() {
+		return AtMostSize(n)
+	}
+
+	if h.N > n {
+		h.N = n
+	}
+
+	return h
+}
+
+// droppedSize returns a hint of how many values are emitted by skipping the first n values of an
+// Iterator with hint h.
+func droppedSize(n int, h SizeHint) SizeHint {
+	if !h.Known() || n <= 0 {
+		return h
+	}
+
+	h.N -= n
+
+	if h.N < 0 {
+		h.N = 0
+	}
+
+	return h
+}
+
+// windowedSize returns a hint of how many windows of the given size and step are made of the values
+// of an Iterator with hint h, including a trailing partial window if partial is set.
+func windowedSize(size, step int, partial bool, h SizeHint) SizeHint {
+	if !h.Known() || size <= 0 || step <= 0 {
+		return SizeHint{}
+	}
+
+	n, covered := 0, 0
+	if h.N >= size {
+		n = (h.N-size)/step + 1
+		covered = (n-1)*step + size
+	}
+
+	// a partial window would start a step after the last full one.
+	if n*step > covered {
+		covered = n * step
+	}
+
+	if partial && h.N > covered {
+		n++
+	} else if partial && h.Kind == SizeAtMost && h.N > 0 {
+		// fewer values may leave some uncovered by the fewer full windows they make.
+		n++
+	}
+
+	h.N = n
+
+	return h
+}
+
+// zippedSize returns a hint of how many values are emitted by zipping the given iterators.
+func zippedSize[T any](iters []Iterator[T]) SizeHint {
+	if len(iters) == 0 {
+		return ExactSize(0)
+	}
+
+	hs := make([]SizeHint, len(iters))
+	for i, iter := range iters {
+		hs[i] = iter.size
+	}
+
+	rounds := shortestSize(hs...)
+	rounds.N *= len(iters)
+
+	return rounds
+}
+
+// shortestSize returns a hint of how many values are emitted by an Iterator stopping after as many
+// values as the shortest of Iterators with the given hints.
+func shortestSize(hs ...SizeHint) SizeHint {
+	// any known size bounds the shortest.
+	shortest := SizeHint{}
+	exact := true
+
+	for _, h := range hs {
+		if h.Kind != SizeExact {
+			exact = false
+		}
+
+		if h.Known() && (!shortest.Known() || h.N < shortest.N) {
+			shortest = AtMostSize(h.N)
+		}
+	}
+
+	if shortest.Known() && exact {
+		shortest.Kind = SizeExact
+	}
+
+	return shortest
+}
+
+// longestSize returns a hint of how many values are emitted by an Iterator continuing for as many
+// values as the longest of Iterators with the given hints.
+func longestSize(hs ...SizeHint) SizeHint {
+	longest := ExactSize(0)
+
+	for _, h := range hs {
+		if !h.Known() {
+			return SizeHint{}
+		}
+
+		if h.Kind != SizeExact {
+			longest.Kind = SizeAtMost
+		}
+
+		if h.N > longest.N {
+			longest.N = h.N
+		}
+	}
+
+	return longest
+}
@@ -0,0 +1,125 @@
+package giter
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestSize(t *testing.T) {
+	xs := []int{1, 2, 3, 4, 5}
+	m, _, _, _ := testMap()
+	even := func(x int) bool { return x%2 == 0 }
+
+	unknown := Make(func(_ chan<- int, _ <-chan interface{}) {})
+
+	tests := []struct {
+		name string
+		iter Iterator[int]
+		want SizeHint
+	}{
+		{"Slice", Slice(xs), ExactSize(5)},
+		{"SliceReversed", SliceReversed(xs), ExactSize(5)},
+		{"MapValues", MapValues(m), ExactSize(3)},
+		{"Range", Range(1, 6), ExactSize(5)},
+		{"RangeBy", RangeBy(10, 55, 10), ExactSize(5)},
+		{"RangeEmpty", Range(6, 1), ExactSize(0)},
+		{"RangeDown", RangeDown(10, 0), ExactSize(10)},
+		{"RangeByInclusive", RangeByInclusive(10, 50, 10), ExactSize(5)},
+		{"RangeByNegative", RangeBy(50, 5, -10), ExactSize(5)},
+		{"RangeZeroStep", RangeBy(0, 10, 0), ExactSize(0)},
+		{"One", One(1), ExactSize(1)},
+		{"Map", Map(func(x int) int { return x }, Slice(xs)), ExactSize(5)},
+		{"MapErr", MapErr(func(x int) (int, error) { return x, nil }, Slice(xs)), AtMostSize(5)},
+		{"Filter", Filter(even, Slice(xs)), AtMostSize(5)},
+		{"FilterMap", Map(func(x int) int { return x }, Filter(even, Slice(xs))), AtMostSize(5)},
+		{"FlatMap", FlatMap(func(x int) []int { return nil }, Slice(xs)), SizeHint{}},
+		{"Concat", Concat(Slice(xs), One(1)), ExactSize(6)},
+		{"ConcatBound", Concat(Slice(xs), Filter(even, One(1))), AtMostSize(6)},
+		{"ConcatUnknown", Concat(Slice(xs), unknown), SizeHint{}},
+		{"Zip", Zip(Slice(xs), Range(0, 3)), ExactSize(6)},
+		{"ZipUnknown", Zip(Slice(xs), Make(func(_ chan<- int, _ <-chan interface{}) {})), AtMostSize(10)},
+		{"Take", Take(3, Slice(xs)), ExactSize(3)},
+		{"TakeMore", Take(10, Slice(xs)), ExactSize(5)},
+		{"TakeUnknown", Take(3, unknown), AtMostSize(3)},
+		{"Drop", Drop(3, Slice(xs)), ExactSize(2)},
+		{"DropMore", Drop(10, Filter(even, Slice(xs))), AtMostSize(0)},
+		{"TakeWhile", TakeWhile(even, Slice(xs)), AtMostSize(5)},
+		{"Zip2", Map(func(Pair[int, int]) int { return 0 }, Zip2(Slice(xs), Range(0, 3))),
+			ExactSize(3)},
+		{"ZipLongest", Map(func(Pair[int, int]) int { return 0 }, ZipLongest(Slice(xs),
+			Filter(even, Range(0, 8)))), AtMostSize(8)},
+		{"Make", Make(func(_ chan<- int, _ <-chan interface{}) {}), SizeHint{}},
+	}
+
+	for _, test := range tests {
+		if out := test.iter.Size(); out != test.want {
+			t.Errorf("TestSize: %v Size() = %v, want %v", test.name, out, test.want)
+		}
+
+		test.iter.Close()
+	}
+
+	fpRange := Range[float64](0, 2.5)
+	defer fpRange.Close()
+
+	if out := fpRange.Size(); out.Kind != SizeAtMost || out.N < 3 {
+		t.Errorf("TestSize: float Range Size() = %v, want at most >= 3", out)
+	}
+
+	chunked := Chunk(2, Slice(xs))
+	defer chunked.Close()
+
+	if out, want := chunked.Size(), ExactSize(3); out != want {
+		t.Errorf("TestSize: Chunk Size() = %v, want %v", out, want)
+	}
+}
+
+func TestSizedCollect(t *testing.T) {
+	xs := []int{1, 2, 3, 4, 5}
+
+	out := ToSlice(Map(func(x int) int { return x }, Slice(xs)))
+
+	if !reflect.DeepEqual(xs, out) || cap(out) != len(xs) {
+		t.Errorf("TestSizedCollect: ToSlice = %v (cap %v), want %v (cap %v)", out, cap(out), xs, len(xs))
+	}
+
+	out = CollectSized(SizedSliceCollector[int](), Slice(xs))
+
+	if !reflect.DeepEqual(xs, out) || cap(out) != len(xs) {
+		t.Errorf(
+			"TestSizedCollect: CollectSized(SizedSliceCollector) = %v (cap %v), want %v (cap %v)",
+			out, cap(out), xs, len(xs))
+	}
+
+	want, _, _, pairs := testMap()
+
+	if m := CollectSized(SizedMapCollector[string, int](), Slice(pairs)); !reflect.DeepEqual(want, m) {
+		t.Errorf("TestSizedCollect: CollectSized(SizedMapCollector) = %v, want %v", m, want)
+	}
+}
+
+func TestSizedCollectHuge(t *testing.T) {
+	// collecting mustn't preallocate for values that a failing Iterator never emits.
+	boom := errors.New("boom")
+	fail := func(int) (int, error) { return 0, boom }
+
+	out, err := ToSliceErr(MapErr(fail, Range(0, 1<<40)))
+
+	if len(out) != 0 || err != boom {
+		t.Errorf("TestSizedCollectHuge: ToSliceErr = %v, %v, want [], %v", out, err, boom)
+	}
+
+	out, err = ToSliceErr(TakeWhile(func(int) bool { return false }, Range(0, 1<<40)))
+
+	if len(out) != 0 || err != nil || cap(out) != 0 {
+		t.Errorf("TestSizedCollectHuge: ToSliceErr = %v (cap %v), %v, want [] (cap 0)", out,
+			cap(out), err)
+	}
+
+	for _, h := range []SizeHint{ExactSize(-1), ExactSize(1 << 40), AtMostSize(10)} {
+		if n := h.capacity(); n < 0 || n > maxCapacity || (h.Kind != SizeExact && n != 0) {
+			t.Errorf("TestSizedCollectHuge: %v capacity() = %v", h, n)
+		}
+	}
+}
@@ -1,103 +1,667 @@
 package giter
 
+import "container/list"
+
 // Map returns an Iterator emitting the values of the given Iterator transformed by the given
 // function.
+//
+// If the given Iterator fails, so does the returned one.
 func Map[T, TP any](f func(T) TP, iter Iterator[T]) Iterator[TP] {
-	return Make(
-		func(out chan<- TP, stopChan <-chan interface{}) {
-			defer iter.Close()
-			for v := range iter.Each {
-				select {
-				case out <- f(v):
-				case <-stopChan:
-					return
-				}
+	// unlike MapErr, Map can't stop early, so emits as many values as the given Iterator.
+	return MapErr(func(v T) (TP, error) { return f(v), nil }, iter).sized(iter.size)
+}
+
+// MapErr returns an Iterator emitting the values of the given Iterator transformed by the given
+// fallible function.
+//
+// The returned Iterator fails with the first error returned by the function, or with the error of
+// the given Iterator.
+func MapErr[T, TP any](f func(T) (TP, error), iter Iterator[T]) Iterator[TP] {
+	up := pull(iter)
+
+	var err error
+
+	// the function may fail on any value, so the given Iterator's size is only a bound.
+	return fromPuller(iter.size.AtMost(), puller[TP]{
+		next: func(stop <-chan interface{}) (mapped TP, ok bool) {
+			v, ok := up.next(stop)
+			if !ok {
+				return mapped, false
 			}
-		})
+
+			mapped, err = f(v)
+
+			return mapped, err == nil
+		},
+		err: func() error {
+			if err != nil {
+				return err
+			}
+
+			return up.Err()
+		},
+		close: up.Close,
+	})
 }
 
 // Filter returns an Iterator emitting the values of the given Iterator which match the given
 // predicate.
+//
+// If the given Iterator fails, so does the returned one.
 func Filter[T any](pred func(T) bool, iter Iterator[T]) Iterator[T] {
-	return Make(
-		func(out chan<- T, stopChan <-chan interface{}) {
-			defer iter.Close()
-			for v := range iter.Each {
-				if pred(v) {
-					select {
-					case out <- v:
-					case <-stopChan:
-						return
-					}
+	return FilterErr(func(v T) (bool, error) { return pred(v), nil }, iter)
+}
+
+// FilterErr returns an Iterator emitting the values of the given Iterator which match the given
+// fallible predicate.
+//
+// The returned Iterator fails with the first error returned by the predicate, or with the error of
+// the given Iterator.
+func FilterErr[T any](pred func(T) (bool, error), iter Iterator[T]) Iterator[T] {
+	up := pull(iter)
+
+	var err error
+
+	return fromPuller(iter.size.AtMost(), puller[T]{
+		next: func(stop <-chan interface{}) (v T, ok bool) {
+			for {
+				v, ok = up.next(stop)
+				if !ok {
+					return v, false
+				}
+
+				keep, predErr := pred(v)
+				if predErr != nil {
+					err = predErr
+					return v, false
+				}
+
+				if keep {
+					return v, true
 				}
 			}
-		})
+		},
+		err: func() error {
+			if err != nil {
+				return err
+			}
+
+			return up.Err()
+		},
+		close: up.Close,
+	})
+}
+
+// Enumerate returns an Iterator emitting the values of the given Iterator along with their
+// positions among them, counting from zero.
+//
+// If the given Iterator fails, so does the returned one.
+func Enumerate[T any](iter Iterator[T]) Iterator[Indexed[T]] {
+	return MapIndexed(func(i int, x T) Indexed[T] { return Indexed[T]{i, x} }, iter)
+}
+
+// MapIndexed is as Map, but the given function also receives the position of each value among
+// those of the given Iterator, counting from zero.
+func MapIndexed[T, R any](f func(int, T) R, iter Iterator[T]) Iterator[R] {
+	i := 0
+
+	return Map(func(x T) R {
+		defer func() { i++ }()
+		return f(i, x)
+	}, iter)
+}
+
+// FilterIndexed is as Filter, but the given predicate also receives the position of each value
+// among those of the given Iterator, counting from zero.
+func FilterIndexed[T any](pred func(int, T) bool, iter Iterator[T]) Iterator[T] {
+	i := 0
+
+	return Filter(func(x T) bool {
+		defer func() { i++ }()
+		return pred(i, x)
+	}, iter)
+}
+
+// Scan returns an Iterator emitting the intermediate values of folding the values of the given
+// Iterator, as Fold would: for each value, the result of calling the given function with it and
+// the previous result, starting with the given initial value (which isn't emitted itself).
+//
+// If the given Iterator fails, so does the returned one.
+func Scan[T, R any](initial R, f func(next T, current R) R, iter Iterator[T]) Iterator[R] {
+	return Map(func(x T) R {
+		initial = f(x, initial)
+		return initial
+	}, iter)
+}
+
+// Take returns an Iterator emitting the first n values emitted by the given Iterator.
+//
+// The given Iterator is closed as soon as its n-th value has been received, so that it stops
+// producing values that would never be emitted; sources that are infinite or expensive may thus
+// be passed to Take.
+//
+// If the given Iterator fails before emitting n values, so does the returned one.
+func Take[T any](n int, iter Iterator[T]) Iterator[T] {
+	up := pull(iter)
+
+	taken := 0
+
+	return fromPuller(takenSize(n, iter.size), puller[T]{
+		next: func(stop <-chan interface{}) (x T, ok bool) {
+			if taken >= n {
+				return x, false
+			}
+
+			x, ok = up.next(stop)
+			if !ok {
+				return x, false
+			}
+
+			taken++
+
+			if taken >= n {
+				up.Close()
+			}
+
+			return x, true
+		},
+		err: func() error {
+			if taken >= n {
+				return nil
+			}
+
+			return up.Err()
+		},
+		close: up.Close,
+	})
+}
+
+// TakeWhile returns an Iterator emitting the values emitted by the given Iterator up to (and
+// excluding) the first one not matching the given predicate.
+//
+// As with Take, the given Iterator is closed as soon as a value doesn't match the predicate.
+//
+// If the given Iterator fails before then, so does the returned one.
+func TakeWhile[T any](pred func(T) bool, iter Iterator[T]) Iterator[T] {
+	up := pull(iter)
+
+	satisfied := false
+
+	return fromPuller(iter.size.AtMost(), puller[T]{
+		next: func(stop <-chan interface{}) (x T, ok bool) {
+			x, ok = up.next(stop)
+			if !ok {
+				return x, false
+			}
+
+			if !pred(x) {
+				satisfied = true
+				up.Close()
+
+				var zero T
+
+				return zero, false
+			}
+
+			return x, true
+		},
+		err: func() error {
+			if satisfied {
+				return nil
+			}
+
+			return up.Err()
+		},
+		close: up.Close,
+	})
+}
+
+// Drop returns an Iterator emitting the values emitted by the given Iterator after skipping the
+// first n of them.
+//
+// If the given Iterator fails, so does the returned one.
+func Drop[T any](n int, iter Iterator[T]) Iterator[T] {
+	up := pull(iter)
+
+	dropped := 0
+
+	return fromPuller(droppedSize(n, iter.size), puller[T]{
+		next: func(stop <-chan interface{}) (x T, ok bool) {
+			for dropped < n {
+				if _, ok := up.next(stop); !ok {
+					return x, false
+				}
+
+				dropped++
+			}
+
+			return up.next(stop)
+		},
+		err:   up.Err,
+		close: up.Close,
+	})
+}
+
+// DropWhile returns an Iterator emitting the values emitted by the given Iterator starting from
+// the first one not matching the given predicate.
+//
+// The predicate isn't called again once a value hasn't matched it.
+//
+// If the given Iterator fails, so does the returned one.
+func DropWhile[T any](pred func(T) bool, iter Iterator[T]) Iterator[T] {
+	up := pull(iter)
+
+	dropping := true
+
+	return fromPuller(iter.size.AtMost(), puller[T]{
+		next: func(stop <-chan interface{}) (x T, ok bool) {
+			for dropping {
+				x, ok = up.next(stop)
+				if !ok {
+					return x, false
+				}
+
+				if !pred(x) {
+					dropping = false
+					return x, true
+				}
+			}
+
+			return up.next(stop)
+		},
+		err:   up.Err,
+		close: up.Close,
+	})
+}
+
+// Distinct returns an Iterator emitting the values of the given Iterator, skipping any equal to one
+// emitted before.
+//
+// Every value emitted is remembered until the returned Iterator is closed; see DistinctLRU for
+// bounding the memory used.
+//
+// If the given Iterator fails, so does the returned one.
+func Distinct[T comparable](iter Iterator[T]) Iterator[T] {
+	return DistinctBy(func(x T) T { return x }, iter)
+}
+
+// DistinctBy is as Distinct, but tells values apart by the keys given by the given function.
+func DistinctBy[T any, K comparable](key func(T) K, iter Iterator[T]) Iterator[T] {
+	return distinctBy[T, K](key, &seenSet[K]{m: map[K]struct{}{}}, iter)
+}
+
+// DistinctLRU is as Distinct, but only remembers up to the given number of values: those seen most
+// recently, whether emitted or skipped. A value is thus emitted again if more than that many
+// other values were seen since it last was.
+func DistinctLRU[T comparable](n int, iter Iterator[T]) Iterator[T] {
+	return DistinctByLRU(n, func(x T) T { return x }, iter)
+}
+
+// DistinctByLRU is as DistinctLRU, but tells values apart by the keys given by the given function.
+func DistinctByLRU[T any, K comparable](n int, key func(T) K, iter Iterator[T]) Iterator[T] {
+	return distinctBy[T, K](key, newSeenLRU[K](n), iter)
+}
+
+// seen remembers the keys of the values emitted by Distinct and friends.
+type seen[K comparable] interface {
+	// add remembers a key, returning true if it wasn't already.
+	add(k K) bool
+
+	// release forgets all keys.
+	release()
+}
+
+// distinctBy implements DistinctBy and DistinctByLRU, skipping values whose keys the given seen
+// already remembers.
+func distinctBy[T any, K comparable](key func(T) K, s seen[K], iter Iterator[T]) Iterator[T] {
+	up := pull(iter)
+
+	return fromPuller(iter.size.AtMost(), puller[T]{
+		next: func(stop <-chan interface{}) (x T, ok bool) {
+			for {
+				x, ok = up.next(stop)
+				if !ok || s.add(key(x)) {
+					return x, ok
+				}
+			}
+		},
+		err: up.Err,
+		close: func() {
+			s.release()
+			up.Close()
+		},
+	})
+}
+
+// seenSet remembers every key.
+type seenSet[K comparable] struct {
+	m map[K]struct{}
+}
+
+func (s *seenSet[K]) add(k K) bool {
+	if _, ok := s.m[k]; ok {
+		return false
+	}
+
+	s.m[k] = struct{}{}
+
+	return true
+}
+
+func (s *seenSet[K]) release() {
+	s.m = nil
+}
+
+// seenLRU remembers up to a given number of the most recently seen keys.
+type seenLRU[K comparable] struct {
+	n int
+
+	// order holds the keys from the most to the least recently seen, and elems their elements.
+	order *list.List
+	elems map[K]*list.Element
+}
+
+func newSeenLRU[K comparable](n int) *seenLRU[K] {
+	return &seenLRU[K]{
+		n:     n,
+		order: list.New(),
+		elems: map[K]*list.Element{},
+	}
+}
+
+func (s *seenLRU[K]) add(k K) bool {
+	if e, ok := s.elems[k]; ok {
+		s.order.MoveToFront(e)
+		return false
+	}
+
+	if s.n <= 0 {
+		return true
+	}
+
+	s.elems[k] = s.order.PushFront(k)
+
+	if s.order.Len() > s.n {
+		delete(s.elems, s.order.Remove(s.order.Back()).(K))
+	}
+
+	return true
+}
+
+func (s *seenLRU[K]) release() {
+	s.order.Init()
+	s.elems = nil
+}
+
+// DedupAdjacent returns an Iterator emitting the values of the given Iterator, skipping any equal
+// to the one just before it.
+//
+// Unlike Distinct, only the last value emitted is remembered.
+//
+// If the given Iterator fails, so does the returned one.
+func DedupAdjacent[T comparable](iter Iterator[T]) Iterator[T] {
+	return DedupAdjacentBy(func(x T) T { return x }, iter)
+}
+
+// DedupAdjacentBy is as DedupAdjacent, but tells values apart by the keys given by the given
+// function.
+func DedupAdjacentBy[T any, K comparable](key func(T) K, iter Iterator[T]) Iterator[T] {
+	up := pull(iter)
+
+	var last K
+	first := true
+
+	return fromPuller(iter.size.AtMost(), puller[T]{
+		next: func(stop <-chan interface{}) (x T, ok bool) {
+			for {
+				x, ok = up.next(stop)
+				if !ok {
+					return x, false
+				}
+
+				k := key(x)
+
+				if first || k != last {
+					first, last = false, k
+					return x, true
+				}
+			}
+		},
+		err:   up.Err,
+		close: up.Close,
+	})
 }
 
 // FlatMap returns an Iterator emitting the 0 or more values for each value emitted by the given
 // Iterator, as produced by the given function.
+//
+// If the given Iterator fails, so does the returned one.
 func FlatMap[T, R any](f func(T) []R, iter Iterator[T]) Iterator[R] {
-	return Make(
-		func(out chan<- R, stopChan <-chan interface{}) {
-			defer iter.Close()
-			for v := range iter.Each {
-				mapped := f(v)
-
-				for _, v := range mapped {
-					select {
-					case out <- v:
-					case <-stopChan:
-						return
-					}
+	return FlatMapErr(func(v T) ([]R, error) { return f(v), nil }, iter)
+}
+
+// FlatMapErr returns an Iterator emitting the 0 or more values for each value emitted by the given
+// Iterator, as produced by the given fallible function.
+//
+// The returned Iterator fails with the first error returned by the function, or with the error of
+// the given Iterator.
+func FlatMapErr[T, R any](f func(T) ([]R, error), iter Iterator[T]) Iterator[R] {
+	up := pull(iter)
+
+	var err error
+
+	// the values mapped from the last value received from up, and how many of them we've emitted.
+	var mapped []R
+	emitted := 0
+
+	return fromPuller(SizeHint{}, puller[R]{
+		next: func(stop <-chan interface{}) (x R, ok bool) {
+			for emitted >= len(mapped) {
+				v, ok := up.next(stop)
+				if !ok {
+					return x, false
 				}
+
+				mapped, err = f(v)
+				emitted = 0
+
+				if err != nil {
+					return x, false
+				}
+			}
+
+			x = mapped[emitted]
+			emitted++
+
+			return x, true
+		},
+		err: func() error {
+			if err != nil {
+				return err
 			}
-		})
+
+			return up.Err()
+		},
+		close: func() {
+			mapped = nil
+			up.Close()
+		},
+	})
 }
 
 // Chunk returns an Iterator emitting slices with the given length of values emitted by the given
 // Iterator.
+//
+// If the given Iterator fails, so does the returned one, after emitting any partial chunk.
 func Chunk[T any](n int, iter Iterator[T]) Iterator[[]T] {
-	return Make(
-		func(out chan<- []T, stopChan <-chan interface{}) {
-			defer iter.Close()
+	up := pull(iter)
 
-			buf := make([]T, 0, n)
+	buf := make([]T, 0, n)
+	exhausted := false
 
-			// returns false if signaled that we need to stop and bail out
-			flush := func() bool {
-				if len(buf) == 0 {
-					return true
+	return fromPuller(chunkedSize(n, iter.size), puller[[]T]{
+		next: func(stop <-chan interface{}) (outs []T, ok bool) {
+			for !exhausted && len(buf) < cap(buf) {
+				v, ok := up.next(stop)
+				if !ok {
+					if stopped(stop) {
+						return nil, false
+					}
+
+					// emit anything remaining before reporting we're done.
+					exhausted = true
+					break
 				}
 
-				outs := make([]T, len(buf))
-				copy(outs, buf)
+				buf = append(buf, v)
+			}
+
+			if len(buf) == 0 {
+				return nil, false
+			}
+
+			outs = make([]T, len(buf))
+			copy(outs, buf)
+
+			clear(&buf)
+
+			return outs, true
+		},
+		err: up.Err,
+		close: func() {
+			clear(&buf)
+			up.Close()
+		},
+	})
+}
+
+// Windows returns an Iterator emitting slices of the given size of consecutive values emitted by
+// the given Iterator, each starting the given step of values after the one before: windows overlap
+// if step is less than size, and values are skipped between them if it's greater. Values that
+// don't make up a full window at the end are dropped; see WindowsPartial to emit them.
+//
+// Each window is a slice of its own, which the returned Iterator doesn't retain.
+//
+// Windows panics if size or step isn't positive. If the given Iterator fails, so does the returned
+// one.
+func Windows[T any](size, step int, iter Iterator[T]) Iterator[[]T] {
+	return windows(size, step, false, iter)
+}
+
+// WindowsPartial is as Windows, but if the given Iterator ends with values not in any full window,
+// it ends by emitting a shorter window holding them: that is, the window starting a step after the
+// last full one, or holding all the values if there's no full window at all.
+func WindowsPartial[T any](size, step int, iter Iterator[T]) Iterator[[]T] {
+	return windows(size, step, true, iter)
+}
+
+// windows implements Windows and WindowsPartial.
+func windows[T any](size, step int, partial bool, iter Iterator[T]) Iterator[[]T] {
+	if size <= 0 || step <= 0 {
+		panic("giter: window size and step must be positive")
+	}
+
+	up := pull(iter)
 
-				select {
-				case out <- outs:
-				case <-stopChan:
-					return false
+	buf := make([]T, 0, size)
+	exhausted := false
+
+	// fresh is set while buf holds values not emitted in any window yet, and skip counts the
+	// values to drop before the next window starts.
+	fresh := false
+	skip := 0
+
+	return fromPuller(windowedSize(size, step, partial, iter.size), puller[[]T]{
+		next: func(stop <-chan interface{}) (outs []T, ok bool) {
+			for !exhausted && len(buf) < cap(buf) {
+				v, ok := up.next(stop)
+				if !ok {
+					if stopped(stop) {
+						return nil, false
+					}
+
+					// emit any partial window before reporting we're done.
+					exhausted = true
+					break
 				}
 
-				buf = buf[:0]
+				if skip > 0 {
+					skip--
+					continue
+				}
 
-				return true
+				buf = append(buf, v)
+				fresh = true
 			}
 
-			for v := range iter.Each {
-				buf = buf[:len(buf)+1]
-				buf[len(buf)-1] = v
+			if !fresh || (len(buf) < cap(buf) && !partial) {
+				clear(&buf)
+				return nil, false
+			}
 
-				if len(buf) == cap(buf) {
-					if !flush() {
-						return
-					}
+			outs = make([]T, len(buf))
+			copy(outs, buf)
+
+			fresh = false
+
+			if step >= len(buf) {
+				skip = step - len(buf)
+				clear(&buf)
+			} else {
+				// slide the rest of the window to the front, clearing what's left behind.
+				kept := copy(buf, buf[step:])
+				rest := buf[kept:]
+				clear(&rest)
+				buf = buf[:kept]
+			}
+
+			return outs, true
+		},
+		err: up.Err,
+		close: func() {
+			clear(&buf)
+			up.Close()
+		},
+	})
+}
+
+// Pairwise returns an Iterator emitting each value emitted by the given Iterator paired with the
+// one after it, as for computing the differences between consecutive values.
+//
+// If the given Iterator fails, so does the returned one.
+func Pairwise[T any](iter Iterator[T]) Iterator[Pair[T, T]] {
+	up := pull(iter)
+
+	var prev T
+	started := false
+
+	return fromPuller(windowedSize(2, 1, false, iter.size), puller[Pair[T, T]]{
+		next: func(stop <-chan interface{}) (p Pair[T, T], ok bool) {
+			if !started {
+				if prev, ok = up.next(stop); !ok {
+					return p, false
 				}
+
+				started = true
+			}
+
+			x, ok := up.next(stop)
+			if !ok {
+				return p, false
 			}
 
-			// flush anything remaining; ignore if we flushed everything since we're
-			// going to return afterwards regardless.
-			_ = flush()
-		})
+			p, prev = Pair[T, T]{prev, x}, x
+
+			return p, true
+		},
+		err: up.Err,
+		close: func() {
+			var zero T
+
+			prev = zero
+			up.Close()
+		},
+	})
 }
 
 // ChunkedFlatMap maps n elements of a given iterator at a time into a new iterator.
@@ -108,59 +672,66 @@ func Chunk[T any](n int, iter Iterator[T]) Iterator[[]T] {
 // were produced.
 // The contents of the input and output slices will be cleared at least as often as every chunk is
 // emitted into the output iterator, to avoid retaining excessive heap space.
+// If the given Iterator fails, so does the returned one, after mapping any partial chunk.
 func ChunkedFlatMap[T, R any](n int, f func([]T, []R) []R, iter Iterator[T]) Iterator[R] {
 	// XXX maybe this should just be MapChunked? don't know that explicitly calling it FlatMap
 	// is necessary, since it's obvious that we're mapping one chunk at a time and we just
 	// receive a slice from the mapping function because we have to receive some sort of type
 	// capable of holding multiple values.
-	return Make(
-		func(out chan<- R, stopChan <-chan interface{}) {
-			defer iter.Close()
+	up := pull(iter)
 
-			buf := make([]T, 0, n)
-			outBuf := make([]R, 0, cap(buf))
+	buf := make([]T, 0, n)
+	outBuf := make([]R, 0, cap(buf))
+	exhausted := false
 
-			// returns false if signaled that we need to stop and bail out
-			flush := func() bool {
-				// once we leave this function we are done with the contents of
-				// these buffers and their contents (and anything it points to)
-				// should be allowed to go away.
-				defer clear(&buf)
-				defer clear(&outBuf)
+	// the values mapped from the last chunk, and how many of them we've emitted.
+	var outs []R
+	emitted := 0
 
-				if len(buf) == 0 {
-					return true
-				}
+	return fromPuller(SizeHint{}, puller[R]{
+		next: func(stop <-chan interface{}) (x R, ok bool) {
+			for emitted >= len(outs) {
+				// we are done with the contents of the last chunk's output (and anything
+				// it points to), which should be allowed to go away.
+				clear(&outs)
+				emitted = 0
 
-				outs := f(buf, outBuf)
-				clear(&buf)
-				var zero R
-
-				for i, x := range outs {
-					select {
-					case out <- x:
-						outs[i] = zero
-					case <-stopChan:
-						return false
+				for !exhausted && len(buf) < cap(buf) {
+					v, ok := up.next(stop)
+					if !ok {
+						if stopped(stop) {
+							return x, false
+						}
+
+						// map anything remaining before reporting we're done.
+						exhausted = true
+						break
 					}
+
+					buf = append(buf, v)
 				}
 
-				return true
+				if len(buf) == 0 {
+					return x, false
+				}
+
+				outs = f(buf, outBuf)
+				clear(&buf)
 			}
 
-			for v := range iter.Each {
-				buf = buf[:len(buf)+1]
-				buf[len(buf)-1] = v
+			var zero R
 
-				if len(buf) == cap(buf) {
-					if !flush() {
-						return
-					}
-				}
-			}
+			x = outs[emitted]
+			outs[emitted] = zero
+			emitted++
 
-			// flush anything remaining; ignore if we flushed everything since we're
-			// going to return afterwards regardless.
-			_ = flush()
-		})
+			return x, true
+		},
+		err: up.Err,
+		close: func() {
+			clear(&buf)
+			clear(&outs)
+			up.Close()
+		},
+	})
 }
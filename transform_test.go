@@ -1,7 +1,11 @@
 package giter
 
 import (
+	"context"
+	"errors"
 	"reflect"
+	"runtime"
+	"strings"
 	"testing"
 )
 
@@ -106,3 +110,434 @@ func TestChunkedFlatMap(t *testing.T) {
 			out, want)
 	}
 }
+
+func TestMapErr(t *testing.T) {
+	xs := []int{1, 2, 3, 4, 5}
+	want := []int{2, 4}
+	wantErr := errors.New("too big")
+
+	f := func(x int) (int, error) {
+		if x > 2 {
+			return 0, wantErr
+		}
+
+		return 2 * x, nil
+	}
+
+	out, err := ToSliceErr(MapErr(f, Slice(xs)))
+
+	if !reflect.DeepEqual(out, want) {
+		t.Errorf("TestMapErr: MapErr(2*x or err, xs) = %v, want = %v", out, want)
+	}
+
+	if err != wantErr {
+		t.Errorf("TestMapErr: err = %v, want = %v", err, wantErr)
+	}
+}
+
+func TestFilterErr(t *testing.T) {
+	xs := []int{1, 2, 3, 4, 5}
+	want := []int{2}
+	wantErr := errors.New("too big")
+
+	f := func(x int) (bool, error) {
+		if x > 3 {
+			return false, wantErr
+		}
+
+		return x%2 == 0, nil
+	}
+
+	out, err := ToSliceErr(FilterErr(f, Slice(xs)))
+
+	if !reflect.DeepEqual(out, want) {
+		t.Errorf("TestFilterErr: FilterErr(!x%%2 or err, xs) = %v, want = %v", out, want)
+	}
+
+	if err != wantErr {
+		t.Errorf("TestFilterErr: err = %v, want = %v", err, wantErr)
+	}
+}
+
+func TestFlatMapErr(t *testing.T) {
+	xs := []int{1, 2, 3, 4, 5}
+	want := []int{1, 0}
+	wantErr := errors.New("too big")
+
+	f := func(x int) ([]int, error) {
+		if x > 1 {
+			return nil, wantErr
+		}
+
+		return []int{x, x / 2}, nil
+	}
+
+	out, err := ToSliceErr(FlatMapErr(f, Slice(xs)))
+
+	if !reflect.DeepEqual(out, want) {
+		t.Errorf("TestFlatMapErr: FlatMapErr(x -> [ x, x / 2 ] or err, xs) = %v, want = %v", out, want)
+	}
+
+	if err != wantErr {
+		t.Errorf("TestFlatMapErr: err = %v, want = %v", err, wantErr)
+	}
+}
+
+func TestErrPropagation(t *testing.T) {
+	wantErr := errors.New("upstream")
+
+	out, err := ToSliceErr(
+		Chunk(2,
+			Map(func(x int) int { return 2 * x },
+				Filter(func(x int) bool { return true },
+					Concat(Slice([]int{1, 2, 3}), Fail[int](wantErr))))))
+
+	want := [][]int{[]int{2, 4}, []int{6}}
+
+	if !reflect.DeepEqual(out, want) {
+		t.Errorf("TestErrPropagation: out = %v, want = %v", out, want)
+	}
+
+	if err != wantErr {
+		t.Errorf("TestErrPropagation: err = %v, want = %v", err, wantErr)
+	}
+}
+
+func TestTake(t *testing.T) {
+	tests := []struct {
+		name string
+		n    int
+		want []int
+	}{
+		{"Some", 3, []int{1, 2, 3}},
+		{"All", 5, []int{1, 2, 3, 4, 5}},
+		{"More", 10, []int{1, 2, 3, 4, 5}},
+		{"None", 0, []int{}},
+	}
+
+	for _, test := range tests {
+		out := ToSlice(Take(test.n, Slice([]int{1, 2, 3, 4, 5})))
+
+		if !reflect.DeepEqual(out, test.want) {
+			t.Errorf("TestTake: %s: Take(%d, {1..=5}) = %v, want = %v", test.name, test.n, out,
+				test.want)
+		}
+	}
+}
+
+func TestTakeClosesUpstream(t *testing.T) {
+	src, exited := naturals(context.Background())
+	iter := Take(3, src)
+	defer iter.Close()
+
+	want := []int{0, 1, 2}
+	out := []int{}
+
+	for x := range iter.Each {
+		out = append(out, x)
+	}
+
+	if !reflect.DeepEqual(out, want) {
+		t.Errorf("TestTakeClosesUpstream: Take(3, naturals) = %v, want = %v", out, want)
+	}
+
+	select {
+	case <-exited:
+	default:
+		t.Errorf("TestTakeClosesUpstream: upstream still producing after Take was satisfied")
+	}
+
+	src, exited = naturals(context.Background())
+	out = ToSlice(TakeWhile(func(x int) bool { return x < 3 }, src))
+
+	if !reflect.DeepEqual(out, want) {
+		t.Errorf("TestTakeClosesUpstream: TakeWhile(x < 3, naturals) = %v, want = %v", out, want)
+	}
+
+	select {
+	case <-exited:
+	default:
+		t.Errorf("TestTakeClosesUpstream: upstream still producing after TakeWhile was satisfied")
+	}
+}
+
+func TestTakeWhile(t *testing.T) {
+	xs := []int{1, 2, 3, 10, 4, 5}
+	want := []int{1, 2, 3}
+
+	out := ToSlice(TakeWhile(func(x int) bool { return x < 5 }, Slice(xs)))
+
+	if !reflect.DeepEqual(out, want) {
+		t.Errorf("TestTakeWhile: TakeWhile(x < 5, %v) = %v, want = %v", xs, out, want)
+	}
+}
+
+func TestDrop(t *testing.T) {
+	tests := []struct {
+		name string
+		n    int
+		want []int
+	}{
+		{"Some", 3, []int{4, 5}},
+		{"All", 5, []int{}},
+		{"More", 10, []int{}},
+		{"None", 0, []int{1, 2, 3, 4, 5}},
+	}
+
+	for _, test := range tests {
+		out := ToSlice(Drop(test.n, Slice([]int{1, 2, 3, 4, 5})))
+
+		if !reflect.DeepEqual(out, test.want) {
+			t.Errorf("TestDrop: %s: Drop(%d, {1..=5}) = %v, want = %v", test.name, test.n, out,
+				test.want)
+		}
+	}
+}
+
+func TestDropWhile(t *testing.T) {
+	xs := []int{1, 2, 3, 10, 4, 5}
+	want := []int{10, 4, 5}
+
+	out := ToSlice(DropWhile(func(x int) bool { return x < 5 }, Slice(xs)))
+
+	if !reflect.DeepEqual(out, want) {
+		t.Errorf("TestDropWhile: DropWhile(x < 5, %v) = %v, want = %v", xs, out, want)
+	}
+}
+
+func TestTakeDropErr(t *testing.T) {
+	boom := errors.New("boom")
+	failing := func() Iterator[int] { return Concat(Slice([]int{1, 2}), Fail[int](boom)) }
+
+	if _, err := ToSliceErr(Take(5, failing())); err != boom {
+		t.Errorf("TestTakeDropErr: Take past failure: err = %v, want %v", err, boom)
+	}
+
+	if out, err := ToSliceErr(Take(2, failing())); err != nil || len(out) != 2 {
+		t.Errorf("TestTakeDropErr: Take before failure: out = %v, err = %v, want 2 values", out,
+			err)
+	}
+
+	if _, err := ToSliceErr(Drop(1, failing())); err != boom {
+		t.Errorf("TestTakeDropErr: Drop: err = %v, want %v", err, boom)
+	}
+}
+
+func TestEnumerate(t *testing.T) {
+	xs := []string{"a", "b", "c"}
+	want := []Indexed[string]{{0, "a"}, {1, "b"}, {2, "c"}}
+
+	if out := ToSlice(Enumerate(Slice(xs))); !reflect.DeepEqual(out, want) {
+		t.Errorf("TestEnumerate: Enumerate(%v) = %v, want = %v", xs, out, want)
+	}
+
+	// indices count values as they come, so start over for each Iterator.
+	enumerateTwice := func() Iterator[Indexed[string]] {
+		return Concat(Enumerate(Slice(xs)), Enumerate(Slice(xs)))
+	}
+
+	if out := ToSlice(enumerateTwice()); !reflect.DeepEqual(out, append(want, want...)) {
+		t.Errorf("TestEnumerate: Enumerate twice = %v, want = %v", out, append(want, want...))
+	}
+}
+
+func TestMapIndexed(t *testing.T) {
+	xs := []int{10, 20, 30}
+	want := []int{10, 21, 32}
+
+	out := ToSlice(MapIndexed(func(i, x int) int { return x + i }, Slice(xs)))
+
+	if !reflect.DeepEqual(out, want) {
+		t.Errorf("TestMapIndexed: MapIndexed(x + i, %v) = %v, want = %v", xs, out, want)
+	}
+}
+
+func TestFilterIndexed(t *testing.T) {
+	xs := []string{"a", "b", "c", "d", "e"}
+	want := []string{"a", "c", "e"}
+
+	out := ToSlice(FilterIndexed(func(i int, _ string) bool { return i%2 == 0 }, Slice(xs)))
+
+	if !reflect.DeepEqual(out, want) {
+		t.Errorf("TestFilterIndexed: FilterIndexed(i %% 2 == 0, %v) = %v, want = %v", xs, out,
+			want)
+	}
+}
+
+func TestScan(t *testing.T) {
+	xs := []int{3, 1, 4, 1, 5}
+	want := []int{3, 3, 4, 4, 5}
+
+	max := func(next, current int) int {
+		if next > current {
+			return next
+		}
+
+		return current
+	}
+
+	if out := ToSlice(Scan(0, max, Slice(xs))); !reflect.DeepEqual(out, want) {
+		t.Errorf("TestScan: Scan(0, max, %v) = %v, want = %v", xs, out, want)
+	}
+
+	// scanning is lazy, so may go on forever.
+	src, exited := naturals(context.Background())
+
+	grow := func(_ int, s string) string { return s + "x" }
+	wantGrown := []string{"x", "xx", "xxx", "xxxx"}
+
+	if out := ToSlice(Take(4, Scan("", grow, src))); !reflect.DeepEqual(out, wantGrown) {
+		t.Errorf("TestScan: Take(4, Scan(\"\", grow, naturals)) = %v, want = %v", out, wantGrown)
+	}
+
+	select {
+	case <-exited:
+	default:
+		t.Errorf("TestScan: upstream still producing after Close")
+	}
+}
+
+func TestDistinct(t *testing.T) {
+	xs := []int{3, 1, 3, 2, 1, 4, 2}
+	want := []int{3, 1, 2, 4}
+
+	if out := ToSlice(Distinct(Slice(xs))); !reflect.DeepEqual(out, want) {
+		t.Errorf("TestDistinct: Distinct(%v) = %v, want = %v", xs, out, want)
+	}
+
+	words := []string{"apple", "avocado", "banana", "blueberry", "cherry"}
+	wantWords := []string{"apple", "banana", "cherry"}
+
+	out := ToSlice(DistinctBy(func(s string) byte { return s[0] }, Slice(words)))
+
+	if !reflect.DeepEqual(out, wantWords) {
+		t.Errorf("TestDistinct: DistinctBy(first letter, %v) = %v, want = %v", words, out,
+			wantWords)
+	}
+}
+
+func TestDistinctLRU(t *testing.T) {
+	tests := []struct {
+		n    int
+		xs   []int
+		want []int
+	}{
+		// 1 is forgotten once 2 and 3 have been seen since.
+		{2, []int{1, 2, 3, 1}, []int{1, 2, 3, 1}},
+		// seeing 1 again keeps it from being forgotten.
+		{2, []int{1, 2, 1, 3, 1}, []int{1, 2, 3}},
+		{3, []int{1, 2, 3, 1, 2, 3}, []int{1, 2, 3}},
+		// remembering nothing skips nothing.
+		{0, []int{1, 1, 1}, []int{1, 1, 1}},
+	}
+
+	for _, test := range tests {
+		if out := ToSlice(DistinctLRU(test.n, Slice(test.xs))); !reflect.DeepEqual(out, test.want) {
+			t.Errorf("TestDistinctLRU: DistinctLRU(%v, %v) = %v, want = %v", test.n, test.xs,
+				out, test.want)
+		}
+	}
+}
+
+func TestDedupAdjacent(t *testing.T) {
+	xs := []int{1, 1, 2, 2, 2, 1, 3, 3}
+	want := []int{1, 2, 1, 3}
+
+	if out := ToSlice(DedupAdjacent(Slice(xs))); !reflect.DeepEqual(out, want) {
+		t.Errorf("TestDedupAdjacent: DedupAdjacent(%v) = %v, want = %v", xs, out, want)
+	}
+
+	// the zero value is a value like any other.
+	zeros := []int{0, 0, 1}
+
+	if out := ToSlice(DedupAdjacent(Slice(zeros))); !reflect.DeepEqual(out, []int{0, 1}) {
+		t.Errorf("TestDedupAdjacent: DedupAdjacent(%v) = %v, want = [0 1]", zeros, out)
+	}
+
+	words := []string{"a", "A", "b", "B", "a"}
+	wantWords := []string{"a", "b", "a"}
+
+	out := ToSlice(DedupAdjacentBy(strings.ToLower, Slice(words)))
+
+	if !reflect.DeepEqual(out, wantWords) {
+		t.Errorf("TestDedupAdjacent: DedupAdjacentBy(lower, %v) = %v, want = %v", words, out,
+			wantWords)
+	}
+}
+
+func TestWindows(t *testing.T) {
+	xs := []int{1, 2, 3, 4, 5}
+
+	tests := []struct {
+		size, step int
+		partial    bool
+		want       [][]int
+	}{
+		{3, 1, false, [][]int{{1, 2, 3}, {2, 3, 4}, {3, 4, 5}}},
+		{3, 1, true, [][]int{{1, 2, 3}, {2, 3, 4}, {3, 4, 5}}},
+		{2, 2, false, [][]int{{1, 2}, {3, 4}}},
+		{2, 2, true, [][]int{{1, 2}, {3, 4}, {5}}},
+		{3, 2, false, [][]int{{1, 2, 3}, {3, 4, 5}}},
+		{4, 3, true, [][]int{{1, 2, 3, 4}, {4, 5}}},
+		{1, 2, false, [][]int{{1}, {3}, {5}}},
+		{2, 3, true, [][]int{{1, 2}, {4, 5}}},
+		{2, 4, true, [][]int{{1, 2}, {5}}},
+		{1, 3, true, [][]int{{1}, {4}}},
+		{6, 1, false, [][]int{}},
+		{6, 1, true, [][]int{{1, 2, 3, 4, 5}}},
+	}
+
+	before := runtime.NumGoroutine()
+
+	for _, test := range tests {
+		windows := Windows[int]
+		if test.partial {
+			windows = WindowsPartial[int]
+		}
+
+		iter := windows(test.size, test.step, Slice(xs))
+
+		size := iter.Size()
+		out := ToSlice(iter)
+
+		if !reflect.DeepEqual(out, test.want) {
+			t.Errorf("TestWindows: size %v, step %v, partial %v: %v, want = %v", test.size,
+				test.step, test.partial, out, test.want)
+		}
+
+		if size != ExactSize(len(test.want)) {
+			t.Errorf("TestWindows: size %v, step %v, partial %v: Size() = %v, want %v",
+				test.size, test.step, test.partial, size, ExactSize(len(test.want)))
+		}
+	}
+
+	checkGoroutines(t, "TestWindows", before)
+}
+
+func TestWindowsMovingAverage(t *testing.T) {
+	samples := []float64{1, 2, 3, 4, 5, 6}
+	want := []float64{2, 3, 4, 5}
+
+	avg := func(xs []float64) float64 { return Average(Slice(xs)).OrElse(0) }
+
+	if out := ToSlice(Map(avg, Windows(3, 1, Slice(samples)))); !reflect.DeepEqual(out, want) {
+		t.Errorf("TestWindowsMovingAverage: out = %v, want = %v", out, want)
+	}
+}
+
+func TestPairwise(t *testing.T) {
+	xs := []int{1, 4, 9, 16}
+	want := []int{3, 5, 7}
+
+	delta := func(p Pair[int, int]) int { return p.Second - p.First }
+
+	if out := ToSlice(Map(delta, Pairwise(Slice(xs)))); !reflect.DeepEqual(out, want) {
+		t.Errorf("TestPairwise: deltas of %v = %v, want = %v", xs, out, want)
+	}
+
+	for _, short := range [][]int{{}, {1}} {
+		if out := ToSlice(Pairwise(Slice(short))); len(out) != 0 {
+			t.Errorf("TestPairwise: Pairwise(%v) = %v, want []", short, out)
+		}
+	}
+}